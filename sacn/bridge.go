@@ -0,0 +1,139 @@
+package sacn
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+)
+
+//ArtNetPort is the UDP port that is used by Art-Net
+const ArtNetPort = 6454
+
+const opArtDMX = 0x5000 //OpCode of ArtDMX packets
+
+var artNetHeader = []byte("Art-Net\x00")
+
+/*
+Bridge relays Art-Net input into sACN output. It reads ArtDMX packets from a connection, maps the Art-Net
+port-address of every packet to a sACN universe and sends the data out with a transmitter. Universes are
+activated on the transmitter when the first packet for them arrives, so configure the destinations of the
+universes on the transmitter (SetMulticast, SetDestinations) before.
+
+Only ArtDMX packets are used, all other Art-Net packets are ignored.
+*/
+type Bridge struct {
+	conn     net.PacketConn
+	tx       *Transmitter
+	mapping  func(portAddress uint16) (universe uint16, ok bool)
+	mu       sync.Mutex
+	channels map[uint16]bridgeUniverse //the universes that were activated by the bridge
+}
+
+//bridgeUniverse is a universe that was activated on the transmitter by a bridge
+type bridgeUniverse struct {
+	ch          chan<- [512]byte
+	deactivated <-chan struct{} //gets closed, if the universe is deactivated on the transmitter
+}
+
+//NewBridge creates a new bridge that reads Art-Net from conn (eg a socket on ArtNetPort) and sends
+//the data out with tx. mapping returns the sACN universe for an Art-Net port-address, or false if the
+//port-address should be ignored. If mapping is nil, ArtNetToUniverse is used.
+func NewBridge(conn net.PacketConn, tx *Transmitter, mapping func(portAddress uint16) (uint16, bool)) *Bridge {
+	if mapping == nil {
+		mapping = ArtNetToUniverse
+	}
+	return &Bridge{
+		conn:     conn,
+		tx:       tx,
+		mapping:  mapping,
+		channels: make(map[uint16]bridgeUniverse),
+	}
+}
+
+//ArtNetToUniverse is the default mapping of a bridge. Art-Net starts with port-address 0, so the
+//port-address 0 is mapped to universe 1, 1 to 2 and so on.
+func ArtNetToUniverse(portAddress uint16) (uint16, bool) {
+	universe := portAddress + 1
+	return universe, checkUniverse(universe) == nil
+}
+
+//Run relays the Art-Net packets until the connection is closed. All universes that were activated by
+//the bridge are deactivated again before Run returns. If a universe is deactivated on the transmitter
+//while the bridge is running, it is activated again with the next packet for it. Returns the error of
+//the connection that stopped the bridge.
+func (b *Bridge) Run() error {
+	defer b.deactivate()
+	buf := make([]byte, 18+512)
+	for {
+		n, _, err := b.conn.ReadFrom(buf)
+		if err != nil {
+			return err
+		}
+		portAddress, data, err := parseArtDMX(buf[:n])
+		if err != nil {
+			continue //no ArtDMX packet
+		}
+		universe, ok := b.mapping(portAddress)
+		if !ok {
+			continue
+		}
+		u, err := b.universe(universe)
+		if err != nil {
+			continue
+		}
+		var frame [512]byte
+		copy(frame[:], data)
+		select {
+		case u.ch <- frame:
+		case <-u.deactivated:
+			//nobody reads the channel anymore, so the next packet activates the universe again
+			b.mu.Lock()
+			delete(b.channels, universe)
+			b.mu.Unlock()
+		}
+	}
+}
+
+//universe returns the activated universe and activates it, if necessary
+func (b *Bridge) universe(universe uint16) (bridgeUniverse, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if u, ok := b.channels[universe]; ok {
+		return u, nil
+	}
+	ch, err := b.tx.Activate(universe)
+	if err != nil {
+		return bridgeUniverse{}, err
+	}
+	u := bridgeUniverse{ch, b.tx.deactivated(universe)}
+	b.channels[universe] = u
+	return u, nil
+}
+
+//deactivate deactivates all universes that were activated by the bridge
+func (b *Bridge) deactivate() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for universe := range b.channels {
+		b.tx.Deactivate(universe)
+		delete(b.channels, universe)
+	}
+}
+
+//parseArtDMX returns the port-address and the DMX data of an ArtDMX packet
+func parseArtDMX(raw []byte) (uint16, []byte, error) {
+	if len(raw) < 18 || !bytes.Equal(raw[:8], artNetHeader) {
+		return 0, nil, errors.New("the packet is no Art-Net packet")
+	}
+	if opCode := uint16(raw[8]) | uint16(raw[9])<<8; opCode != opArtDMX { //OpCode is little endian
+		return 0, nil, fmt.Errorf("the packet is no ArtDMX packet, OpCode was 0x%04X", opCode)
+	}
+	portAddress := uint16(raw[15]&0x7F)<<8 | uint16(raw[14]) //Net and SubUni
+	length := int(raw[16])<<8 | int(raw[17])
+	if length > 512 || 18+length > len(raw) {
+		return 0, nil, fmt.Errorf("the ArtDMX packet has an invalid length of %v", length)
+	}
+	return portAddress, raw[18 : 18+length], nil
+}
@@ -0,0 +1,155 @@
+package sacn
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+//artDMX creates an ArtDMX packet for the port-address with the data
+func artDMX(portAddress uint16, data []byte) []byte {
+	p := append([]byte("Art-Net\x00"), 0x00, 0x50, 0, 14, 0, 0, byte(portAddress), byte(portAddress>>8),
+		byte(len(data)>>8), byte(len(data)))
+	return append(p, data...)
+}
+
+func TestArtNetToUniverse(t *testing.T) {
+	tests := []struct {
+		portAddress uint16
+		universe    uint16
+		ok          bool
+	}{
+		{0, 1, true},
+		{1, 2, true},
+		{0x1234, 0x1235, true},
+		{63998, 63999, true},
+		{63999, 0, false},
+	}
+	for _, tt := range tests {
+		univ, ok := ArtNetToUniverse(tt.portAddress)
+		if ok != tt.ok || ok && univ != tt.universe {
+			t.Errorf("Port-address %v: wrong mapping! Was: %v, %v", tt.portAddress, univ, ok)
+		}
+	}
+}
+
+func TestParseArtDMX(t *testing.T) {
+	portAddress, data, err := parseArtDMX(artDMX(0x0102, []byte{1, 2, 3, 4}))
+	if err != nil || portAddress != 0x0102 || len(data) != 4 || data[3] != 4 {
+		t.Errorf("Wrong output! Was: %v, %v, %v", portAddress, data, err)
+	}
+	poll := artDMX(0, nil)
+	poll[9] = 0x20 //ArtPoll
+	tooShort := artDMX(0, []byte{1, 2})
+	for _, raw := range [][]byte{nil, []byte("no Art-Net"), poll, tooShort[:19]} {
+		if _, _, err := parseArtDMX(raw); err == nil {
+			t.Errorf("%v should not be a valid ArtDMX packet!", raw)
+		}
+	}
+}
+
+func TestBridge(t *testing.T) {
+	packets, stop := listenTestPackets(t)
+	defer stop()
+
+	tx, err := NewTransmitter("127.0.0.1:0", [16]byte{1}, "bridge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.SetDestinations(10, []string{"127.0.0.1"})
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bridge := NewBridge(conn, tx, func(portAddress uint16) (uint16, bool) {
+		return portAddress + 10, portAddress == 0
+	})
+	done := make(chan error)
+	go func() {
+		done <- bridge.Run()
+	}()
+
+	sender, err := net.Dial("udp4", conn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sender.Close()
+	sender.Write(artDMX(1, []byte{9, 9})) //not mapped
+	sender.Write(artDMX(0, []byte{7, 8}))
+	for {
+		select {
+		case p := <-packets:
+			if p.Universe() != 10 {
+				t.Fatalf("Wrong universe! Was: %v", p.Universe())
+			}
+			if p.Data()[0] != 7 {
+				continue //the initial packet of the universe
+			}
+		case <-time.After(time.Second):
+			t.Fatal("The Art-Net data was not relayed!")
+		}
+		break
+	}
+	conn.Close()
+	<-done
+	if tx.IsActivated(10) {
+		t.Error("The universe should be deactivated after the bridge has stopped!")
+	}
+}
+
+func TestBridgeDeactivated(t *testing.T) {
+	packets, stop := listenTestPackets(t)
+	defer stop()
+
+	tx, err := NewTransmitter("127.0.0.1:0", [16]byte{1}, "bridge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.SetDestinations(1, []string{"127.0.0.1"})
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bridge := NewBridge(conn, tx, nil)
+	done := make(chan error)
+	go func() {
+		done <- bridge.Run()
+	}()
+	sender, err := net.Dial("udp4", conn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sender.Close()
+
+	//waitFor sends the value until it is relayed
+	waitFor := func(value byte) {
+		timeout := time.After(2 * time.Second)
+		for {
+			sender.Write(artDMX(0, []byte{value}))
+			select {
+			case p := <-packets:
+				if p.Data()[0] == value && !p.StreamTerminated() {
+					return
+				}
+			case <-time.After(50 * time.Millisecond):
+			case <-timeout:
+				t.Fatalf("The value %v was not relayed!", value)
+			}
+		}
+	}
+	waitFor(1)
+	//the universe is deactivated elsewhere, the bridge must neither hang nor stop relaying
+	if err := tx.Deactivate(1); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(2)
+	conn.Close()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("The bridge did not stop!")
+	}
+	if tx.IsActivated(1) {
+		t.Error("The universe should be deactivated after the bridge has stopped!")
+	}
+}
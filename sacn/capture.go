@@ -0,0 +1,124 @@
+package sacn
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+/*
+The capture file format is a header followed by one frame per packet:
+
+	header: "sACNcap1" (8 bytes)
+	frame:  timestamp in unix nanoseconds (8 bytes, big endian), payload length (2 bytes, big endian),
+	        payload (the raw UDP payload)
+*/
+var captureHeader = []byte("sACNcap1")
+
+//Recorder writes timestamped raw packets to a capture file. Use ReceiverSocket.SetRecorder to record
+//all packets of a receiver and Player to replay them.
+type Recorder struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+//NewRecorder creates a new Recorder that writes to w and writes the header of the capture file.
+func NewRecorder(w io.Writer) (*Recorder, error) {
+	if _, err := w.Write(captureHeader); err != nil {
+		return nil, err
+	}
+	return &Recorder{w: w}, nil
+}
+
+//Record writes the packet with the time it was received. It is safe to call Record concurrently.
+func (rec *Recorder) Record(t time.Time, packet []byte) error {
+	if len(packet) > 0xFFFF {
+		return fmt.Errorf("the packet is too long to be recorded: %v bytes", len(packet))
+	}
+	frame := make([]byte, 10, 10+len(packet))
+	binary.BigEndian.PutUint64(frame, uint64(t.UnixNano()))
+	binary.BigEndian.PutUint16(frame[8:], uint16(len(packet)))
+	frame = append(frame, packet...)
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	_, err := rec.w.Write(frame)
+	return err
+}
+
+//Player reads a capture file that was written by a Recorder.
+type Player struct {
+	r *bufio.Reader
+}
+
+//NewPlayer creates a new Player that reads from r. Returns an error, if r is no capture file.
+func NewPlayer(r io.Reader) (*Player, error) {
+	p := &Player{bufio.NewReader(r)}
+	header := make([]byte, len(captureHeader))
+	if _, err := io.ReadFull(p.r, header); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(header, captureHeader) {
+		return nil, errors.New("the file is no sACN capture file")
+	}
+	return p, nil
+}
+
+//Next returns the next packet and the time it was recorded. Returns io.EOF after the last packet.
+func (p *Player) Next() (time.Time, []byte, error) {
+	frame := make([]byte, 10)
+	if _, err := io.ReadFull(p.r, frame); err != nil {
+		return time.Time{}, nil, err
+	}
+	t := time.Unix(0, int64(binary.BigEndian.Uint64(frame)))
+	packet := make([]byte, binary.BigEndian.Uint16(frame[8:]))
+	if _, err := io.ReadFull(p.r, packet); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return time.Time{}, nil, err
+	}
+	return t, packet, nil
+}
+
+//Play calls handler for all remaining packets with the original timing between them, eg
+//NewDataPacketRaw to parse them. Returns nil after the last packet, or the first error of the
+//handler or the file.
+func (p *Player) Play(handler func(packet []byte) error) error {
+	var first time.Time
+	start := time.Now()
+	for {
+		t, packet, err := p.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if first.IsZero() {
+			first = t
+		}
+		time.Sleep(t.Sub(first) - time.Since(start))
+		if err := handler(packet); err != nil {
+			return err
+		}
+	}
+}
+
+//PlayTo sends all remaining packets with the original timing via conn to addr, eg to a receiver on
+//this host.
+func (p *Player) PlayTo(conn net.PacketConn, addr net.Addr) error {
+	return p.Play(func(packet []byte) error {
+		_, err := conn.WriteTo(packet, addr)
+		return err
+	})
+}
+
+//recorderHolder wraps the Recorder, so that an atomic.Value always stores the same type
+type recorderHolder struct {
+	rec *Recorder
+}
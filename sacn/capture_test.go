@@ -0,0 +1,86 @@
+package sacn
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestRecorderPlayer(t *testing.T) {
+	var file bytes.Buffer
+	rec, err := NewRecorder(&file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	data := NewDataPacket()
+	packets := [][]byte{data.Bytes(), {1, 2, 3}, {}}
+	for i, p := range packets {
+		if err := rec.Record(start.Add(time.Duration(i)*20*time.Millisecond), p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	player, err := NewPlayer(bytes.NewReader(file.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts, p, err := player.Next()
+	if err != nil || !ts.Equal(start) || !bytes.Equal(p, packets[0]) {
+		t.Errorf("Wrong first packet! Was: %v, %v, %v", ts, p, err)
+	}
+
+	player, _ = NewPlayer(bytes.NewReader(file.Bytes()))
+	var played [][]byte
+	playStart := time.Now()
+	if err := player.Play(func(p []byte) error {
+		played = append(played, p)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(played) != 3 || !bytes.Equal(played[1], packets[1]) || len(played[2]) != 0 {
+		t.Errorf("Wrong packets were played: %v", played)
+	}
+	if elapsed := time.Since(playStart); elapsed < 40*time.Millisecond {
+		t.Errorf("The original timing was not kept! Playing took %v", elapsed)
+	}
+	if _, _, err := player.Next(); err != io.EOF {
+		t.Errorf("Expected io.EOF after the last packet, got: %v", err)
+	}
+
+	if _, err := NewPlayer(bytes.NewReader([]byte("no capture file"))); err == nil {
+		t.Error("A file without header should not be accepted!")
+	}
+	truncated, _ := NewPlayer(bytes.NewReader(file.Bytes()[:20]))
+	if _, _, err := truncated.Next(); err != io.ErrUnexpectedEOF {
+		t.Errorf("Expected io.ErrUnexpectedEOF for a truncated file, got: %v", err)
+	}
+}
+
+func TestReceiverRecorder(t *testing.T) {
+	p := NewDataPacket()
+	p.SetUniverse(1)
+	conn := &fakeConn{reads: []fakeRead{{data: p.Bytes()}}}
+	r := newTestReceiver()
+	r.socket = conn
+	var file bytes.Buffer
+	rec, _ := NewRecorder(&file)
+	r.SetRecorder(rec)
+	r.Start()
+	for start := time.Now(); time.Since(start) < time.Second; time.Sleep(time.Millisecond) {
+		if r.Stats().PacketsReceived == 1 {
+			break
+		}
+	}
+	r.Close()
+
+	player, err := NewPlayer(&file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, raw, err := player.Next(); err != nil || !bytes.Equal(raw, p.Bytes()) {
+		t.Errorf("The packet was not recorded! Was: %v, %v", raw, err)
+	}
+}
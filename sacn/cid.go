@@ -0,0 +1,40 @@
+package sacn
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+)
+
+//NewCID returns a new random CID, that is a version 4 UUID. A source should use the same CID for its
+//whole lifetime, so store the CID, if the source should be recognized after a restart.
+func NewCID() [16]byte {
+	var cid [16]byte
+	if _, err := rand.Read(cid[:]); err != nil {
+		panic(fmt.Sprintf("could not read random bytes for a CID: %v", err))
+	}
+	cid[6] = cid[6]&0x0f | 0x40 //version 4
+	cid[8] = cid[8]&0x3f | 0x80 //variant RFC 4122
+	return cid
+}
+
+//CIDString formats the CID as UUID in the canonical form xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx
+func CIDString(cid [16]byte) string {
+	return fmt.Sprintf("%x-%x-%x-%x-%x", cid[0:4], cid[4:6], cid[6:8], cid[8:10], cid[10:16])
+}
+
+//CIDFromString parses a UUID in the canonical form xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx into a CID.
+//Upper and lower case hex digits are allowed.
+func CIDFromString(s string) ([16]byte, error) {
+	var cid [16]byte
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return cid, fmt.Errorf("%q is not a UUID of the form xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx", s)
+	}
+	//only the dashes between the groups are removed, any other character has to be a hex digit
+	raw, err := hex.DecodeString(s[0:8] + s[9:13] + s[14:18] + s[19:23] + s[24:36])
+	if err != nil {
+		return cid, fmt.Errorf("%q is not a valid UUID: %v", s, err)
+	}
+	copy(cid[:], raw)
+	return cid, nil
+}
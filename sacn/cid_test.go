@@ -0,0 +1,38 @@
+package sacn
+
+import "testing"
+
+func TestNewCID(t *testing.T) {
+	cid := NewCID()
+	if cid == NewCID() {
+		t.Error("Two random CIDs should not be equal!")
+	}
+	if cid[6]>>4 != 4 || cid[8]>>6 != 2 {
+		t.Errorf("CID is not a version 4 UUID: %v", CIDString(cid))
+	}
+}
+
+func TestCIDString(t *testing.T) {
+	cid := [16]byte{0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd, 0xef, 0xfe, 0xdc, 0xba, 0x98, 0x76, 0x54, 0x32, 0x10}
+	str := "01234567-89ab-cdef-fedc-ba9876543210"
+	if got := CIDString(cid); got != str {
+		t.Errorf("Wrong output! Was: %v; Should've been: %v", got, str)
+	}
+	for _, s := range []string{str, "01234567-89AB-CDEF-FEDC-BA9876543210"} {
+		got, err := CIDFromString(s)
+		if err != nil || got != cid {
+			t.Errorf("Could not parse %v: %v, %v", s, got, err)
+		}
+	}
+	random := NewCID()
+	if got, err := CIDFromString(CIDString(random)); err != nil || got != random {
+		t.Errorf("Round trip failed! Was: %v; Should've been: %v (%v)", got, random, err)
+	}
+	for _, s := range []string{"", "0123456789abcdeffedcba9876543210", "01234567-89ab-cdef-fedc-ba987654321g",
+		"01234567-89ab-cdef-fedcba-9876543210", "{01234567-89ab-cdef-fedc-ba9876543210}",
+		"01234567-89ab-cdef-fedc-ba98765432--", "0123456--89ab-cdef-fedc-ba9876543210"} {
+		if _, err := CIDFromString(s); err == nil {
+			t.Errorf("%q should not be a valid UUID!", s)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package sacn
+
+import (
+	"net"
+	"time"
+
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+//packetConn is the socket that is used by the receiver. It hides the differences between IPv4 and IPv6.
+type packetConn interface {
+	//ReadFrom reads a packet and returns the destination address of the packet, eg the multicast-group.
+	//dst is nil, if the destination is not known.
+	ReadFrom(b []byte) (n int, dst net.IP, src net.Addr, err error)
+	SetDeadline(t time.Time) error
+	//JoinGroup joins the multicast-group of the given universe
+	JoinGroup(ifi *net.Interface, universe uint16) error
+	//LeaveGroup leaves the multicast-group of the given universe
+	LeaveGroup(ifi *net.Interface, universe uint16) error
+	Close() error
+}
+
+//ipv4Conn is a packetConn for IPv4 sockets, that uses the 239.255.x.x multicast-groups
+type ipv4Conn struct {
+	*ipv4.PacketConn
+}
+
+//newIPv4Conn wraps the connection and enables the control message with the destination address.
+//If the operating system does not support it, the destination of the packets is unknown.
+func newIPv4Conn(conn net.PacketConn) ipv4Conn {
+	c := ipv4Conn{ipv4.NewPacketConn(conn)}
+	c.SetControlMessage(ipv4.FlagDst, true)
+	return c
+}
+
+func (c ipv4Conn) ReadFrom(b []byte) (int, net.IP, net.Addr, error) {
+	n, cm, src, err := c.PacketConn.ReadFrom(b)
+	if cm == nil {
+		return n, nil, src, err
+	}
+	return n, cm.Dst, src, err
+}
+
+func (c ipv4Conn) JoinGroup(ifi *net.Interface, universe uint16) error {
+	return c.PacketConn.JoinGroup(ifi, calcMulticastUDPAddr(universe))
+}
+
+func (c ipv4Conn) LeaveGroup(ifi *net.Interface, universe uint16) error {
+	return c.PacketConn.LeaveGroup(ifi, calcMulticastUDPAddr(universe))
+}
+
+//ipv6Conn is a packetConn for IPv6 sockets, that uses the ff18::83:0:0:x multicast-groups
+type ipv6Conn struct {
+	*ipv6.PacketConn
+}
+
+//newIPv6Conn wraps the connection like newIPv4Conn does
+func newIPv6Conn(conn net.PacketConn) ipv6Conn {
+	c := ipv6Conn{ipv6.NewPacketConn(conn)}
+	c.SetControlMessage(ipv6.FlagDst, true)
+	return c
+}
+
+func (c ipv6Conn) ReadFrom(b []byte) (int, net.IP, net.Addr, error) {
+	n, cm, src, err := c.PacketConn.ReadFrom(b)
+	if cm == nil {
+		return n, nil, src, err
+	}
+	return n, cm.Dst, src, err
+}
+
+func (c ipv6Conn) JoinGroup(ifi *net.Interface, universe uint16) error {
+	return c.PacketConn.JoinGroup(ifi, calcMulticastUDPAddrIPv6(universe))
+}
+
+func (c ipv6Conn) LeaveGroup(ifi *net.Interface, universe uint16) error {
+	return c.PacketConn.LeaveGroup(ifi, calcMulticastUDPAddrIPv6(universe))
+}
+
+//plainConn is a packetConn for connections that are no UDP sockets, eg for tests. It does not support
+//multicast, so joining and leaving multicast-groups does nothing. The destination of packets is unknown.
+type plainConn struct {
+	net.PacketConn
+}
+
+func (c plainConn) ReadFrom(b []byte) (int, net.IP, net.Addr, error) {
+	n, src, err := c.PacketConn.ReadFrom(b)
+	return n, nil, src, err
+}
+
+func (c plainConn) JoinGroup(ifi *net.Interface, universe uint16) error {
+	return nil
+}
+
+func (c plainConn) LeaveGroup(ifi *net.Interface, universe uint16) error {
+	return nil
+}
+
+//newPacketConn wraps the given connection into a packetConn. UDP sockets use the multicast-groups of
+//their address family.
+func newPacketConn(conn net.PacketConn) packetConn {
+	udp, ok := conn.(*net.UDPConn)
+	if !ok {
+		return plainConn{conn}
+	}
+	if addr, ok := udp.LocalAddr().(*net.UDPAddr); ok && addr.IP.To4() == nil && len(addr.IP) == net.IPv6len {
+		return newIPv6Conn(conn)
+	}
+	return newIPv4Conn(conn)
+}
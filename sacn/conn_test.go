@@ -0,0 +1,236 @@
+package sacn
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+//timeoutError is returned by the fakeConn, if there is nothing to read
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+//fakeRead is the result of one read of the fakeConn
+type fakeRead struct {
+	data []byte
+	dst  net.IP //the destination of the packet, nil if unknown
+	err  error
+}
+
+//fakeConn is a packetConn that returns the given reads one after the other
+type fakeConn struct {
+	mu     sync.Mutex
+	reads  []fakeRead
+	joined []uint16
+	events []string //all joins and leaves with the interface name
+}
+
+func (c *fakeConn) ReadFrom(b []byte) (int, net.IP, net.Addr, error) {
+	c.mu.Lock()
+	if len(c.reads) == 0 {
+		c.mu.Unlock()
+		time.Sleep(time.Millisecond)
+		return 0, nil, nil, timeoutError{}
+	}
+	read := c.reads[0]
+	c.reads = c.reads[1:]
+	c.mu.Unlock()
+	if read.err != nil {
+		return 0, nil, nil, read.err
+	}
+	return copy(b, read.data), read.dst, &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 5568}, nil
+}
+
+func (c *fakeConn) SetDeadline(t time.Time) error { return nil }
+
+func (c *fakeConn) JoinGroup(ifi *net.Interface, universe uint16) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.joined = append(c.joined, universe)
+	c.events = append(c.events, fmt.Sprintf("join %v %v", ifi.Name, universe))
+	return nil
+}
+
+func (c *fakeConn) LeaveGroup(ifi *net.Interface, universe uint16) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.events = append(c.events, fmt.Sprintf("leave %v %v", ifi.Name, universe))
+	return nil
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func TestListenerInterfaceDown(t *testing.T) {
+	p := NewDataPacket()
+	p.SetUniverse(1)
+	p.SetData([]byte{1})
+	conn := &fakeConn{}
+	for i := 0; i < maxReadFailures+2; i++ {
+		conn.reads = append(conn.reads, fakeRead{err: errors.New("network is down")})
+	}
+	conn.reads = append(conn.reads, fakeRead{data: p.Bytes()})
+
+	r := newTestReceiver()
+	r.socket = conn
+	r.multicastInterface = &net.Interface{Index: 1, Name: "fake"}
+	r.joined[1] = true
+	errs := make(chan ReceiveError, 10)
+	r.OnError(func(err ReceiveError) {
+		errs <- err
+	})
+	data := make(chan DataPacket, 1)
+	r.OnData(1, func(p DataPacket) {
+		data <- p
+	})
+	r.Start()
+	defer r.Close()
+
+	select {
+	case err := <-errs:
+		if err.Err != ErrInterfaceDown {
+			t.Errorf("Wrong error! Was: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("ErrInterfaceDown was not reported!")
+	}
+	select {
+	case <-data:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Data was not received after the interface was up again!")
+	}
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	if len(conn.joined) != 1 || conn.joined[0] != 1 {
+		t.Errorf("The multicast-groups were not joined again! Joined: %v", conn.joined)
+	}
+	select {
+	case err := <-errs:
+		t.Errorf("Unexpected error: %v", err)
+	default:
+	}
+}
+
+func TestReadBackoff(t *testing.T) {
+	if readBackoff(1) != 10*time.Millisecond || readBackoff(2) != 20*time.Millisecond {
+		t.Errorf("Wrong backoff! Was: %v, %v", readBackoff(1), readBackoff(2))
+	}
+	if readBackoff(100) != time.Second {
+		t.Errorf("Backoff should be at most one second! Was: %v", readBackoff(100))
+	}
+}
+
+func TestListenerTruncatedPacket(t *testing.T) {
+	p := NewDataPacket()
+	p.SetUniverse(1)
+	p.SetData([]byte{1})
+	//a valid data packet with trailing bytes, that does not fit into the buffer
+	tooLong := append(p.Bytes(), make([]byte, maxPacketSize)...)
+	p.SetData([]byte{2})
+	conn := &fakeConn{reads: []fakeRead{{data: tooLong}, {data: p.Bytes()}}}
+
+	r := newTestReceiver()
+	r.socket = conn
+	data := make(chan DataPacket, 2)
+	r.OnData(1, func(p DataPacket) {
+		data <- p
+	})
+	r.Start()
+	defer r.Close()
+	select {
+	case got := <-data:
+		if got.Data()[0] != 2 {
+			t.Errorf("The truncated packet was processed! Data: %v", got.Data())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Data was not received!")
+	}
+}
+
+func TestListenerDeliversSnapshots(t *testing.T) {
+	//the listener reuses its buffer, so every delivered packet has to be a copy
+	conn := &fakeConn{}
+	p := NewDataPacket()
+	p.SetUniverse(1)
+	for i := 0; i < 100; i++ {
+		p.SetSequence(byte(i))
+		p.SetData([]byte{byte(i), byte(i)})
+		conn.reads = append(conn.reads, fakeRead{data: p.Bytes()})
+	}
+
+	r := newTestReceiver()
+	r.socket = conn
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	seen := make(map[byte]bool)
+	wg.Add(100)
+	r.OnData(1, func(p DataPacket) {
+		defer wg.Done()
+		time.Sleep(5 * time.Millisecond) //read slowly, while the next packets are received
+		mu.Lock()
+		defer mu.Unlock()
+		seen[p.Data()[0]] = true
+	})
+	r.Start()
+	defer r.Close()
+	wg.Wait()
+	if len(seen) != 100 {
+		t.Errorf("The data of delivered packets was overwritten! Only %v different values", len(seen))
+	}
+}
+
+func TestStrictDestination(t *testing.T) {
+	packet := func(universe uint16, seq, value byte) []byte {
+		p := NewDataPacket()
+		p.SetUniverse(universe)
+		p.SetSequence(seq)
+		p.SetData([]byte{value})
+		return p.Bytes()
+	}
+	conn := &fakeConn{reads: []fakeRead{
+		{data: packet(2, 0, 3), dst: net.IPv4(127, 0, 0, 1)},       //unicast is always accepted
+		{data: packet(2, 1, 9), dst: UniverseToMulticastIP(2)},     //leaked from a group that is not joined
+		{data: packet(2, 2, 8), dst: calcMulticastIPv6(2)},         //the same for IPv6
+		{data: packet(1, 0, 1), dst: UniverseToMulticastIP(1)},     //joined
+		{data: packet(1, 1, 2), dst: net.IPv4(239, 255, 255, 255)}, //no sACN group
+	}}
+
+	r := newTestReceiver()
+	r.socket = conn
+	r.multicastInterface = &net.Interface{Index: 1, Name: "fake"}
+	r.SetStrictDestination(true)
+	if err := r.JoinUniverse(1); err != nil {
+		t.Fatal(err)
+	}
+	received := make(chan DataPacket, 10)
+	r.OnData(1, func(p DataPacket) { received <- p })
+	r.Start()
+	defer r.Close()
+
+	select {
+	case p := <-received:
+		if p.Data()[0] != 1 {
+			t.Errorf("Wrong data of the joined universe: %v", p.Data())
+		}
+		if !p.DestinationAddr().Equal(UniverseToMulticastIP(1)) {
+			t.Errorf("Wrong destination address! Was: %v", p.DestinationAddr())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("The packet of the joined multicast-group was not received!")
+	}
+	time.Sleep(20 * time.Millisecond) //give the last packet the time to be handled
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+	last2, last1 := r.lastDatas[2].lastPacket, r.lastDatas[1].lastPacket
+	if data := last2.Data(); data[0] != 3 {
+		t.Errorf("Packets of a multicast-group that was not joined should have been dropped, data: %v", data)
+	}
+	if data := last1.Data(); data[0] != 1 {
+		t.Errorf("Packets of an unknown multicast-group should have been dropped, data: %v", data)
+	}
+}
@@ -1,14 +1,57 @@
 package sacn
 
 import (
+	"bytes"
+	"encoding/hex"
 	"fmt"
 	"math"
+	"net"
+	"strings"
 )
 
 const (
 	vectorRootE131Data   = 4 //VECTOR_ROOT_E131_DATA
 	vectorE131DataPacket = 2 //VECTOR_E131_DATA_PACKET
 	vectorDmpSetProperty = 0x2
+	dmpAddressType       = 0xa1 //address and data type: 2-byte addresses, relative, range of properties
+)
+
+//DMX start codes that are used by sACN packets
+const (
+	//StartCodeDimmer is the start code of normal DMX data (NULL start code)
+	StartCodeDimmer = 0x00
+	//StartCodeText is the start code of ASCII text packets
+	StartCodeText = 0x17
+	//StartCodeRDM is the start code of RDM packets
+	StartCodeRDM = 0xCC
+	//StartCodePerAddressPriority is the DMX start code of packets that carry a priority for every
+	//DMX channel instead of DMX data
+	StartCodePerAddressPriority = 0xDD
+)
+
+//The priorities of sources as defined by E1.31
+const (
+	//DefaultPriority is the priority of new data packets and the default of E1.31
+	DefaultPriority = 100
+	//MaxPriority is the highest valid priority, higher values are out of the specification
+	MaxPriority = 200
+)
+
+//The offsets of the layers and fields in the bytes of a data packet, see DataPacket.Raw. Multi-byte
+//fields are big-endian.
+const (
+	OffsetRootLayer    = 0   //the root layer with the preamble, the ACN packet identifier and the CID
+	OffsetCID          = 22  //16 bytes
+	OffsetFramingLayer = 38  //the framing layer with the flags and length field
+	OffsetSourceName   = 44  //64 bytes, null-terminated UTF-8
+	OffsetPriority     = 108 //1 byte
+	OffsetSyncAddress  = 109 //2 bytes
+	OffsetSequence     = 111 //1 byte
+	OffsetOptions      = 112 //1 byte
+	OffsetUniverse     = 113 //2 bytes
+	OffsetDMPLayer     = 115 //the DMP layer with the flags and length field
+	OffsetStartCode    = 125 //1 byte, the first property value
+	OffsetData         = 126 //the DMX data, up to 512 bytes
 )
 
 var constHeader = []byte{0, 0x10, 0, 0, 0x41, 0x53,
@@ -18,11 +61,13 @@ var constHeader = []byte{0, 0x10, 0, 0, 0x41, 0x53,
 type DataPacket struct {
 	data   []byte
 	length uint16
+	source net.Addr
+	dest   net.IP
 }
 
 //NewDataPacket creates a new DataPacket with an empty 638-length byte slice
 func NewDataPacket() DataPacket {
-	p := DataPacket{make([]byte, 638), 126}
+	p := DataPacket{data: make([]byte, 638), length: 126}
 	//Set constants: at index [0;16[
 	p.replace(0, constHeader)
 	//Set vectors:
@@ -32,34 +77,141 @@ func NewDataPacket() DataPacket {
 	//set initial FAL
 	p.setFAL(126)
 	//set address and data type
-	p.data[118] = 0xa1
+	p.data[118] = dmpAddressType
 	//set address increment
 	p.data[122] = 0x1
 	//Default priority:
-	p.SetPriority(100)
+	p.SetPriority(DefaultPriority)
 
 	return p
 }
 
-//NewDataPacketRaw creates a new DataPacket based on the given raw bytes
+//NewDataPacketRaw creates a new DataPacket based on the given raw bytes. The length fields of all
+//layers are checked, so that malformed packets return an error instead of a broken DataPacket.
+//Bytes after the length that is given by the packet are ignored. The bytes are copied, so the given
+//slice can be reused afterwards.
 func NewDataPacketRaw(raw []byte) (DataPacket, error) {
 	var p DataPacket
 	//Check the length of the raw bytes
 	if len(raw) < 126 {
 		return p, fmt.Errorf("The given raw bytes are too short! Min length is 126 was %v", len(raw))
 	}
-	p = NewDataPacket()
-	//Make the array 638 long
-	if len(raw) < 638 { //Add 0 if too short
-		raw = append(raw, make([]byte, 638-len(raw))...)
-	} else if len(raw) > 638 { //cut off the last bits if too long
-		raw = raw[:638]
+	if err := checkDataVectors(raw); err != nil {
+		return p, err
+	}
+	//the property value count contains the start code, so it is [1-513]
+	count := int(getAsUint32(raw[123:125]))
+	if count < 1 || count > 513 {
+		return p, fmt.Errorf("the property value count has to be in [1-513], was %v", count)
+	}
+	length := 125 + count
+	if len(raw) < length {
+		return p, fmt.Errorf("the packet has %v bytes, but %v bytes are specified", len(raw), length)
+	}
+	//check the flags and length of the root, framing and dmp layer
+	if err := checkLayers(raw[:length], 16, 38, 115); err != nil {
+		return p, err
 	}
-	p.data = append([]byte(nil), raw...) //make a copy of the slice, we do not want to use a reference
-	p.length = uint16(getAsUint32(raw[123:125]) + 125)
+	p = DataPacket{data: make([]byte, 638), length: uint16(length)}
+	copy(p.data, raw[:length]) //make a copy of the slice, we do not want to use a reference
 	return p, nil
 }
 
+//checkPacketIdentifier checks the ACN packet identifier of the raw bytes, so that other traffic on the
+//port is not taken for sACN
+func checkPacketIdentifier(raw []byte) error {
+	if len(raw) < 16 || !bytes.Equal(raw[4:16], constHeader[4:16]) {
+		return fmt.Errorf("the ACN packet identifier is invalid, the packet is no sACN packet")
+	}
+	return nil
+}
+
+//checkLayers checks the flags and length fields of the layers that start at the given indices. Every
+//layer has to reach to the end of the raw bytes.
+func checkLayers(raw []byte, layers ...int) error {
+	for _, layer := range layers {
+		if flags := raw[layer] >> 4; flags != 0x7 {
+			return fmt.Errorf("the flags at index %v have to be 0x7, were %#x", layer, flags)
+		}
+		if length := falLength(raw[layer : layer+2]); length != len(raw)-layer {
+			return fmt.Errorf("the length field at index %v is %v, but should be %v",
+				layer, length, len(raw)-layer)
+		}
+	}
+	return nil
+}
+
+//checkDataVectors checks the ACN packet identifier, the vectors of all layers and the DMP addressing
+//of the raw bytes of a data packet, which have to be at least 126 bytes long
+func checkDataVectors(raw []byte) error {
+	if err := checkPacketIdentifier(raw); err != nil {
+		return err
+	}
+	if vector := getAsUint32(raw[18:22]); vector != vectorRootE131Data {
+		return fmt.Errorf("the root vector has to be %v for data packets, was %v", vectorRootE131Data, vector)
+	}
+	if vector := getAsUint32(raw[40:44]); vector != vectorE131DataPacket {
+		return fmt.Errorf("the framing vector has to be %v for data packets, was %v",
+			vectorE131DataPacket, vector)
+	}
+	//the DMX data is only aligned to the DMX channels with the standard DMP addressing
+	if raw[117] != vectorDmpSetProperty {
+		return fmt.Errorf("the DMP vector has to be %v, was %v", vectorDmpSetProperty, raw[117])
+	}
+	if raw[118] != dmpAddressType {
+		return fmt.Errorf("the address and data type has to be %#x, was %#x", dmpAddressType, raw[118])
+	}
+	if first := getAsUint32(raw[119:121]); first != 0 {
+		return fmt.Errorf("the first property address has to be 0, was %v", first)
+	}
+	if incr := getAsUint32(raw[121:123]); incr != 1 {
+		return fmt.Errorf("the address increment has to be 1, was %v", incr)
+	}
+	return nil
+}
+
+/*
+Validate checks that the packet can be send as valid E1.31 data packet. This is the case for every
+packet that was created with NewDataPacket, but hand-built packets, eg via Raw and NewDataPacketRaw,
+may have been changed. It checks:
+  - the ACN packet identifier, the vectors of all layers and the DMP addressing
+  - the priority in [0-200]
+  - the universe in [1-63999]
+  - the count of DMX slots including the start code in [1-513]
+  - the priorities in [0-200] for packets with per-address priorities (start code 0xDD)
+
+The flags and length fields are not checked, because they are recalculated for sending.
+*/
+func (d DataPacket) Validate() error {
+	if len(d.data) < 126 || d.length < 126 {
+		return fmt.Errorf("the packet is too short, use NewDataPacket to create a packet")
+	}
+	if err := checkDataVectors(d.data); err != nil {
+		return err
+	}
+	if prio := d.Priority(); prio > MaxPriority {
+		return fmt.Errorf("the priority was %v and therefore is not in range [0-200]", prio)
+	}
+	if err := checkUniverse(d.Universe()); err != nil {
+		return err
+	}
+	if sync := d.SyncAddress(); sync != 0 && checkUniverse(sync) != nil {
+		return fmt.Errorf("the sync address %v is neither 0 nor in the range [1-63999]", sync)
+	}
+	if count := int(d.length) - 125; count > 513 {
+		return fmt.Errorf("the property value count has to be in [1-513], was %v", count)
+	}
+	if d.IsPerAddressPriority() {
+		for i, prio := range d.Data() {
+			if prio > MaxPriority {
+				return fmt.Errorf("the priority of channel %v was %v and therefore is not in range [0-200]",
+					i+1, prio)
+			}
+		}
+	}
+	return nil
+}
+
 //Set the FAL values in the byte slice according to the length
 //Note: Length is the length of the whole message!
 //Also sets the property value count!
@@ -91,41 +243,62 @@ func (d *DataPacket) copy() DataPacket {
 	return DataPacket{
 		data:   copySlice,
 		length: d.length,
+		source: d.source,
+		dest:   d.dest,
 	}
 }
 
+//SourceAddr returns the network address of the device that has sent this packet. It is nil for
+//packets that were not received from the network. Note that the CID and not the address identifies
+//a source, the address is only meant for diagnostics.
+func (d *DataPacket) SourceAddr() net.Addr {
+	return d.source
+}
+
+//DestinationAddr returns the IP address this packet was sent to. For multicast it is the group of the
+//universe, see MulticastIPToUniverse; otherwise it is the unicast address of the receiver. Use
+//DestinationAddr().IsMulticast() to tell them apart. Like SourceAddr this is meant for diagnostics, eg
+//of IGMP or routing problems. It is nil for packets that were not received from the network, or when
+//the operating system does not report the destination (see ReceiverSocket.SetStrictDestination).
+func (d *DataPacket) DestinationAddr() net.IP {
+	return d.dest
+}
+
 //SetCID sets the CID unique identifier
 func (d *DataPacket) SetCID(cid [16]byte) {
 	d.replace(22, cid[0:16])
 }
 
-//CID returns the cid that is set for this object
+//CID returns the cid that is set for this object. The CID is the unique identifier of the source that
+//has sent this packet and does not change over the lifetime of the source.
 func (d *DataPacket) CID() [16]byte {
 	tmpArray := [16]byte{}
 	copy(tmpArray[:], d.data[22:38])
 	return tmpArray
 }
 
-//SetSourceName sets the source name field to the given string values.
-//Note that only the first 64 characters are used!
-func (d *DataPacket) SetSourceName(s string) {
-	b := [64]byte{}
-	copy(b[:], []byte(s))
-	d.replace(44, b[:64])
+//SetSourceName sets the source name field to the given string value. The name has to be valid UTF-8
+//with at most 63 bytes, because the 64 bytes of the field are null-terminated. Returns an error
+//otherwise, in which case the packet is not changed.
+func (d *DataPacket) SetSourceName(s string) error {
+	name, err := encodeSourceName(s)
+	if err != nil {
+		return err
+	}
+	d.replace(44, name)
+	return nil
 }
 
 //SourceName returns the stored source name. Note that the source name max length is 64!
+//The name is cut off at the first null byte, so the padding of the field is never returned.
+//Invalid UTF-8 is replaced with the replacement character U+FFFD.
 func (d *DataPacket) SourceName() string {
-	i := 44 //the ending index for the string, because it is 0 terminated
-	for i < 108 && d.data[i] != 0 {
-		i++
-	}
-	return string(d.data[44:i])
+	return decodeSourceName(d.data[44:108])
 }
 
 //SetPriority sets the priority field for the packet. Value must be [0-200]!
 func (d *DataPacket) SetPriority(prio byte) error {
-	if prio > 200 {
+	if prio > MaxPriority {
 		return fmt.Errorf("the priority was %v and therefore is not in range [0-200]", prio)
 	}
 	d.data[108] = prio
@@ -133,6 +306,7 @@ func (d *DataPacket) SetPriority(prio byte) error {
 }
 
 //Priority returns the byte value of the priorty field of the packet. Value range: [0-200]
+//This is the priority with which the source sends data on the universe of this packet.
 func (d *DataPacket) Priority() byte {
 	return d.data[108]
 }
@@ -192,6 +366,19 @@ func (d *DataPacket) ForceSync() bool {
 	return d.getOptionsBit(5)
 }
 
+//The flags of the options field of a data packet, see Options
+const (
+	OptionPreviewData      = 1 << 7
+	OptionStreamTerminated = 1 << 6
+	OptionForceSync        = 1 << 5
+)
+
+//Options returns the raw options field of the framing layer. Use the Option* constants to inspect the
+//flags, or the accessors PreviewData, StreamTerminated and ForceSync. The other bits are reserved.
+func (d *DataPacket) Options() byte {
+	return d.data[112]
+}
+
 func (d *DataPacket) setOptionsBit(bit byte, value bool) {
 	if value {
 		d.data[112] = d.data[112] | byte(math.Pow(2, float64(bit)))
@@ -214,6 +401,18 @@ func (d *DataPacket) Universe() uint16 {
 	return uint16(getAsUint32(d.data[113:115]))
 }
 
+//FirstPropertyAddress returns the DMP address of the first property value, which is the start code.
+//This is always 0 for packets that were parsed with NewDataPacketRaw.
+func (d *DataPacket) FirstPropertyAddress() uint16 {
+	return uint16(getAsUint32(d.data[119:121]))
+}
+
+//AddressIncrement returns the DMP address increment between the property values.
+//This is always 1 for packets that were parsed with NewDataPacketRaw.
+func (d *DataPacket) AddressIncrement() uint16 {
+	return uint16(getAsUint32(d.data[121:123]))
+}
+
 //SetDmxStartCode sets the DMX start code that is transmitted together with the DMX data
 func (d *DataPacket) SetDmxStartCode(startCode byte) {
 	d.data[125] = startCode
@@ -224,10 +423,34 @@ func (d *DataPacket) DmxStartCode() byte {
 	return d.data[125]
 }
 
-//SetData sets the dmx data for the given DataPacket
-func (d *DataPacket) SetData(data []byte) {
+//IsPerAddressPriority returns true, if the packet carries per-address priorities instead of DMX data.
+//This is the case if the start code is 0xDD.
+func (d *DataPacket) IsPerAddressPriority() bool {
+	return d.DmxStartCode() == StartCodePerAddressPriority
+}
+
+//PerChannelPriority returns the priority for every DMX channel. For packets with the start code 0xDD
+//these are the priorities that are carried as data, channels that are not included have the priority 0.
+//The per-address priority 0 means that the source does not send the channel, so the receiver never uses
+//the data of the source for this channel.
+//For all other packets every channel that is included in the data has the priority of the packet.
+func (d *DataPacket) PerChannelPriority() [512]byte {
+	prios := [512]byte{}
+	if d.IsPerAddressPriority() {
+		copy(prios[:], d.Data())
+		return prios
+	}
+	for i := range d.Data() {
+		prios[i] = d.Priority()
+	}
+	return prios
+}
+
+//SetData sets the dmx data for the given DataPacket. Returns an error if the data is longer
+//than 512 bytes, in which case the packet is not changed.
+func (d *DataPacket) SetData(data []byte) error {
 	if len(data) > 512 {
-		data = data[0:512]
+		return fmt.Errorf("the data was %v bytes long, but only 512 bytes are allowed", len(data))
 	}
 	//make the length a multiply of 2
 	if len(data)%2 != 0 { //add a 0 to make the length sufficient
@@ -235,13 +458,125 @@ func (d *DataPacket) SetData(data []byte) {
 	}
 	d.setFAL(uint16(126 + len(data)))
 	d.replace(126, data)
+	return nil
 }
 
 //Data returns the DMX data that is set for this DataPacket. Length: [0-512]
+//The slice contains exactly the slots that are given in the packet (see DataLength) without the
+//start code, so a source that sends less than 512 slots does not have trailing zeros in the data.
 func (d *DataPacket) Data() []byte {
 	return d.data[126:d.length]
 }
 
+//CopyData copies the DMX data into dst and returns the count of copied bytes, which is the minimum of
+//len(dst) and DataLength. Unlike a copy of Data, this does not allocate, so a buffer can be reused for
+//every frame. Bytes of dst after the data are not changed.
+func (d *DataPacket) CopyData(dst []byte) int {
+	return copy(dst, d.data[126:d.length])
+}
+
+//Channel returns the value of the DMX channel with the address in [1-512]. Channels that are not
+//contained in the data of the packet have the value 0.
+func (d *DataPacket) Channel(addr int) (byte, error) {
+	if addr < 1 || addr > 512 {
+		return 0, fmt.Errorf("the channel address has to be in [1-512], was %v", addr)
+	}
+	if addr > d.DataLength() {
+		return 0, nil
+	}
+	return d.data[125+addr], nil
+}
+
+//SetChannel sets the value of the DMX channel with the address in [1-512]. If the data of the packet is
+//shorter than the address, it is extended with 0.
+func (d *DataPacket) SetChannel(addr int, value byte) error {
+	if addr < 1 || addr > 512 {
+		return fmt.Errorf("the channel address has to be in [1-512], was %v", addr)
+	}
+	if addr > d.DataLength() {
+		data := make([]byte, addr)
+		copy(data, d.Data())
+		d.SetData(data)
+	}
+	d.data[125+addr] = value
+	return nil
+}
+
+//DataLength returns the count of DMX slots in the packet without the start code. This is the property
+//value count of the packet minus 1. Note that SetData pads data with an odd length with a 0.
+func (d *DataPacket) DataLength() int {
+	return int(d.length) - 126
+}
+
+//Bytes returns the packet as it is send over the network. The flags and length fields of all
+//layers are recalculated before. The returned slice is a copy and can be modified freely.
+func (d *DataPacket) Bytes() []byte {
+	d.setFAL(d.length)
+	return append([]byte(nil), d.getBytes()...)
+}
+
+//Raw returns the complete packet with all layers, eg to read fields that have no accessor. Unlike
+//Bytes, the flags and length fields are not recalculated, so for a received packet the bytes are
+//returned as they were received. See the Offset* constants for the positions of the fields.
+//The returned slice is a copy, so changes have no effect on the packet.
+func (d *DataPacket) Raw() []byte {
+	return append([]byte(nil), d.getBytes()...)
+}
+
+//RootVector returns the vector of the root layer, which is 4 for data packets
+func (d *DataPacket) RootVector() uint32 {
+	return getAsUint32(d.data[18:22])
+}
+
+//FramingVector returns the vector of the framing layer, which is 2 for data packets
+func (d *DataPacket) FramingVector() uint32 {
+	return getAsUint32(d.data[40:44])
+}
+
 func (d *DataPacket) getBytes() []byte {
 	return d.data[:d.length]
 }
+
+//String returns a summary of the packet for logging, eg:
+//
+//	universe 1, priority 100, sequence 5, source "name", CID 01020300-0000-0000-0000-000000000000,
+//	start code 0x00, 512 slots [255 0 12 7 ... 0 0 0 0]
+//
+//Only the first and the last 4 DMX values are included.
+func (d DataPacket) String() string {
+	if len(d.data) < 126 {
+		return "DataPacket{}"
+	}
+	data := d.Data()
+	values := fmt.Sprint(data)
+	if len(data) > 8 {
+		values = fmt.Sprintf("[%v ... %v]", strings.Trim(fmt.Sprint(data[:4]), "[]"),
+			strings.Trim(fmt.Sprint(data[len(data)-4:]), "[]"))
+	}
+	return fmt.Sprintf("universe %v, priority %v, sequence %v, source %q, CID %v, start code 0x%02X, %v slots %v",
+		d.Universe(), d.Priority(), d.Sequence(), d.SourceName(), CIDString(d.CID()), d.DmxStartCode(),
+		len(data), values)
+}
+
+//Hexdump returns the raw bytes of the packet as hex dump, split into the root, framing and DMP layer.
+//The flags and length fields are not recalculated, so the bytes are dumped as they were received.
+//The dump of a packet that was not created with NewDataPacket is empty.
+func (d *DataPacket) Hexdump() string {
+	if len(d.data) < 126 {
+		return ""
+	}
+	var b strings.Builder
+	layers := []struct {
+		name       string
+		start, end int
+	}{
+		{"Root layer", 0, 38},
+		{"Framing layer", 38, 115},
+		{"DMP layer", 115, int(d.length)},
+	}
+	for _, layer := range layers {
+		fmt.Fprintf(&b, "%v [%v:%v]:\n", layer.name, layer.start, layer.end)
+		b.WriteString(hex.Dump(d.data[layer.start:layer.end]))
+	}
+	return b.String()
+}
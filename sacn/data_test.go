@@ -2,7 +2,10 @@ package sacn
 
 import (
 	"bytes"
+	"fmt"
 	"math/rand"
+	"net"
+	"strings"
 	"testing"
 )
 
@@ -78,6 +81,12 @@ func TestSetPriority(t *testing.T) {
 	if err == nil {
 		t.Error("Err was nil! Should have been an error!")
 	}
+	if err := p.SetPriority(MaxPriority + 1); err == nil || p.Priority() != prio {
+		t.Errorf("Priority 201 should have been rejected! Was: %v", p.Priority())
+	}
+	if err := p.SetPriority(MaxPriority); err != nil {
+		t.Error(err)
+	}
 }
 
 func TestPriority(t *testing.T) {
@@ -133,12 +142,525 @@ func TestSetData(t *testing.T) {
 	if !bytes.Equal(i, p.Data()) {
 		t.Error("DMX data was not set or getted properly!")
 	}
-	i = make([]byte, 600)
+	i = make([]byte, 512)
 	for j := range i {
 		i[j] = byte(rand.Uint32())
 	}
 	p.SetData(i)
-	if !bytes.Equal(i[0:512], p.Data()) {
+	if !bytes.Equal(i, p.Data()) {
 		t.Errorf("DMX data was not set or getted properly! Was: %v \nShouldbe: %v", p.Data(), i)
 	}
+	if err := p.SetData(make([]byte, 513)); err == nil {
+		t.Error("Err was nil! Data longer than 512 bytes should have been rejected!")
+	}
+	if !bytes.Equal(i, p.Data()) {
+		t.Error("DMX data should not have been changed by rejected data!")
+	}
+}
+
+func TestSetSourceNameLimit(t *testing.T) {
+	p := NewDataPacket()
+	//61 ascii characters and a two byte character are exactly at the limit of 63 bytes
+	s := strings.Repeat("a", 61) + "ä"
+	if err := p.SetSourceName(s); err != nil {
+		t.Fatal(err)
+	}
+	if p.SourceName() != s || p.data[44+63] != 0 {
+		t.Errorf("Wrong source name! Was: %q", p.SourceName())
+	}
+	//the null byte does not fit anymore
+	if err := p.SetSourceName(strings.Repeat("a", 62) + "ä"); err == nil {
+		t.Error("Source name with 64 bytes should have been rejected!")
+	}
+	if err := p.SetSourceName("\xffname"); err == nil {
+		t.Error("Invalid UTF-8 should have been rejected!")
+	}
+	if p.SourceName() != s {
+		t.Errorf("Rejected source name should not have changed the packet! Was: %q", p.SourceName())
+	}
+	//shorter names are padded with null bytes
+	if err := p.SetSourceName("Lichtpult 光"); err != nil {
+		t.Fatal(err)
+	}
+	if p.SourceName() != "Lichtpult 光" || !bytes.Equal(p.data[44+13:108], make([]byte, 64-13)) {
+		t.Errorf("Source name was not padded properly! Was: %q", p.data[44:108])
+	}
+}
+
+func TestBytes(t *testing.T) {
+	p := NewDataPacket()
+	p.SetUniverse(1)
+	p.SetData([]byte{1, 2, 3, 4})
+	b := p.Bytes()
+	if len(b) != 130 {
+		t.Errorf("Wrong length! Was: %v; Should've been: %v", len(b), 130)
+	}
+	//root layer, framing layer and dmp layer lengths
+	if b[16] != 0x70 || b[17] != 130-16 || b[38] != 0x70 || b[39] != 130-38 ||
+		b[115] != 0x70 || b[116] != 130-115 {
+		t.Errorf("Wrong flags and length fields! Was: %v", b[:126])
+	}
+	b[126] = 100
+	if p.Data()[0] != 1 {
+		t.Error("Bytes should have returned a copy!")
+	}
+	parsed, err := NewDataPacketRaw(p.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(parsed.Data(), p.Data()) || parsed.Universe() != 1 {
+		t.Error("Parsed packet is not equal to the build one!")
+	}
+}
+
+func TestSourceNameRaw(t *testing.T) {
+	p := NewDataPacket()
+	raw := p.Bytes()
+	//the name is followed by null bytes and some garbage after the first null
+	copy(raw[44:108], append([]byte("console"), 0, 0, 'x', 'y'))
+	parsed, err := NewDataPacketRaw(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.SourceName() != "console" {
+		t.Errorf("Source name was not cut off at the first null! Was: %q", parsed.SourceName())
+	}
+	//a name that fills the whole field has no null at all
+	copy(raw[44:108], strings.Repeat("n", 64))
+	parsed, _ = NewDataPacketRaw(raw)
+	if parsed.SourceName() != strings.Repeat("n", 64) {
+		t.Errorf("Wrong source name! Was: %q", parsed.SourceName())
+	}
+	//invalid UTF-8 is replaced, eg a multibyte character that was cut off by the source
+	copy(raw[44:108], append([]byte("Pult \xe5\x85"), 0))
+	parsed, _ = NewDataPacketRaw(raw)
+	if parsed.SourceName() != "Pult \uFFFD\uFFFD" {
+		t.Errorf("Invalid UTF-8 was not replaced! Was: %q", parsed.SourceName())
+	}
+}
+
+func TestPerChannelPriority(t *testing.T) {
+	p := NewDataPacket()
+	p.SetPriority(50)
+	p.SetData([]byte{1, 2})
+	if p.IsPerAddressPriority() {
+		t.Error("Packet with start code 0 should not carry priorities!")
+	}
+	prios := p.PerChannelPriority()
+	if prios[0] != 50 || prios[1] != 50 || prios[2] != 0 {
+		t.Errorf("Wrong priorities! Was: %v", prios[:4])
+	}
+	p.SetDmxStartCode(0xDD)
+	p.SetData([]byte{100, 0, 200})
+	if !p.IsPerAddressPriority() {
+		t.Error("Packet with start code 0xDD should carry priorities!")
+	}
+	prios = p.PerChannelPriority()
+	if prios[0] != 100 || prios[1] != 0 || prios[2] != 200 || prios[3] != 0 {
+		t.Errorf("Wrong priorities! Was: %v", prios[:4])
+	}
+}
+
+func TestNewDataPacketRawMalformed(t *testing.T) {
+	p := NewDataPacket()
+	p.SetData(make([]byte, 512))
+	valid := p.Bytes()
+	//every truncated packet has to be rejected
+	for i := 0; i < len(valid); i++ {
+		if _, err := NewDataPacketRaw(valid[:i]); err == nil {
+			t.Errorf("Packet truncated to %v bytes should have been rejected!", i)
+		}
+	}
+	//trailing bytes are ignored
+	parsed, err := NewDataPacketRaw(append(append([]byte(nil), valid...), make([]byte, 100)...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed.Data()) != 512 {
+		t.Errorf("Wrong data length! Was: %v", len(parsed.Data()))
+	}
+	//a property value count larger than 513 must not be accepted
+	tooLong := append([]byte(nil), valid...)
+	tooLong[123], tooLong[124] = 0x02, 0x02
+	if _, err := NewDataPacketRaw(append(tooLong, make([]byte, 1000)...)); err == nil {
+		t.Error("Packet with too large property value count should have been rejected!")
+	}
+	//a wrong length field must not be accepted
+	wrongFal := append([]byte(nil), valid...)
+	wrongFal[39]++
+	if _, err := NewDataPacketRaw(wrongFal); err == nil {
+		t.Error("Packet with wrong framing length should have been rejected!")
+	}
+	wrongFlags := append([]byte(nil), valid...)
+	wrongFlags[115] &= 0x0F
+	if _, err := NewDataPacketRaw(wrongFlags); err == nil {
+		t.Error("Packet with wrong flags should have been rejected!")
+	}
+	//random bytes must never panic
+	for i := 0; i < 1000; i++ {
+		raw := make([]byte, rand.Intn(1200))
+		rand.Read(raw)
+		if rand.Intn(2) == 0 && len(raw) >= len(valid) {
+			copy(raw, valid[:125]) //keep the header, so that the length checks are reached
+		}
+		NewDataPacketRaw(raw)
+	}
+}
+
+func TestNewDataPacketRawIdentifier(t *testing.T) {
+	p := NewDataPacket()
+	valid := p.Bytes()
+	if _, err := NewDataPacketRaw(valid); err != nil {
+		t.Fatal(err)
+	}
+	artnet := append([]byte(nil), valid...)
+	copy(artnet[4:16], []byte("Art-Net\x00"))
+	if _, err := NewDataPacketRaw(artnet); err == nil {
+		t.Error("Packet with wrong identifier should have been rejected!")
+	}
+	wrongRoot := append([]byte(nil), valid...)
+	wrongRoot[21] = vectorRootE131Extended
+	if _, err := NewDataPacketRaw(wrongRoot); err == nil {
+		t.Error("Packet with wrong root vector should have been rejected!")
+	}
+	wrongFraming := append([]byte(nil), valid...)
+	wrongFraming[43] = vectorE131ExtendedSynchronization
+	if _, err := NewDataPacketRaw(wrongFraming); err == nil {
+		t.Error("Packet with wrong framing vector should have been rejected!")
+	}
+}
+
+func TestSourceAddr(t *testing.T) {
+	p := NewDataPacket()
+	if p.SourceAddr() != nil {
+		t.Errorf("A new packet should not have a source address! Was: %v", p.SourceAddr())
+	}
+	p.source = &net.UDPAddr{IP: net.IPv4(192, 168, 1, 2), Port: 5568}
+	c := p.copy()
+	if c.SourceAddr() != p.SourceAddr() {
+		t.Errorf("The copy should keep the source address! Was: %v", c.SourceAddr())
+	}
+}
+
+func TestDestinationAddr(t *testing.T) {
+	p := NewDataPacket()
+	if p.DestinationAddr() != nil {
+		t.Errorf("A new packet should not have a destination address! Was: %v", p.DestinationAddr())
+	}
+	p.dest = UniverseToMulticastIP(1)
+	c := p.copy()
+	if !c.DestinationAddr().Equal(p.DestinationAddr()) {
+		t.Errorf("The copy should keep the destination address! Was: %v", c.DestinationAddr())
+	}
+}
+
+func TestDataPacketString(t *testing.T) {
+	p := NewDataPacket()
+	p.SetUniverse(1)
+	p.SetSequence(5)
+	p.SetSourceName("name")
+	p.SetCID([16]byte{1, 2, 3})
+	data := make([]byte, 512)
+	data[0], data[1], data[511] = 255, 12, 7
+	p.SetData(data)
+	want := `universe 1, priority 100, sequence 5, source "name", CID 01020300-0000-0000-0000-000000000000, ` +
+		`start code 0x00, 512 slots [255 12 0 0 ... 0 0 0 7]`
+	if got := p.String(); got != want {
+		t.Errorf("Wrong output!\nWas:            %v\nShould've been: %v", got, want)
+	}
+	p.SetData([]byte{1, 2})
+	if got := fmt.Sprint(p); !strings.HasSuffix(got, "2 slots [1 2]") {
+		t.Errorf("Wrong output! Was: %v", got)
+	}
+	if got := fmt.Sprint(DataPacket{}); got != "DataPacket{}" {
+		t.Errorf("Wrong output for an empty packet! Was: %v", got)
+	}
+}
+
+func TestDataPacketHexdump(t *testing.T) {
+	p := NewDataPacket()
+	p.SetData([]byte{0xAB})
+	dump := p.Hexdump()
+	for _, want := range []string{"Root layer [0:38]", "Framing layer [38:115]", "DMP layer [115:128]", "41 53 43 2d", "ab"} {
+		if !strings.Contains(dump, want) {
+			t.Errorf("Hexdump does not contain %q:\n%v", want, dump)
+		}
+	}
+	var empty DataPacket
+	if dump := empty.Hexdump(); dump != "" {
+		t.Errorf("The dump of an empty packet should be empty! Was: %v", dump)
+	}
+}
+
+func TestCopyData(t *testing.T) {
+	p := NewDataPacket()
+	p.SetData([]byte{1, 2, 3, 4})
+	dst := []byte{9, 9, 9, 9, 9, 9}
+	if n := p.CopyData(dst); n != 4 || !bytes.Equal(dst, []byte{1, 2, 3, 4, 9, 9}) {
+		t.Errorf("Wrong copy! Was: %v, %v", n, dst)
+	}
+	short := make([]byte, 2)
+	if n := p.CopyData(short); n != 2 || !bytes.Equal(short, []byte{1, 2}) {
+		t.Errorf("Wrong copy into a short buffer! Was: %v, %v", n, short)
+	}
+	dst[0] = 100
+	if p.Data()[0] != 1 {
+		t.Error("CopyData should copy the data!")
+	}
+}
+
+func BenchmarkData(b *testing.B) {
+	p := NewDataPacket()
+	p.SetData(make([]byte, 512))
+	b.Run("Data", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			data := append([]byte(nil), p.Data()...)
+			_ = data
+		}
+	})
+	b.Run("CopyData", func(b *testing.B) {
+		b.ReportAllocs()
+		dst := make([]byte, 512)
+		for i := 0; i < b.N; i++ {
+			p.CopyData(dst)
+		}
+	})
+}
+
+func TestDataLength(t *testing.T) {
+	p := NewDataPacket()
+	if p.DataLength() != 0 {
+		t.Errorf("A new packet should have no data! Was: %v", p.DataLength())
+	}
+	data := make([]byte, 24)
+	for i := range data {
+		data[i] = byte(i + 1)
+	}
+	p.SetData(data)
+	//a packet that advertises 24 slots, followed by some garbage
+	raw := append(p.Bytes(), 0xFF, 0xFF)
+	parsed, err := NewDataPacketRaw(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.DataLength() != 24 || !bytes.Equal(parsed.Data(), data) {
+		t.Errorf("Wrong data! Length: %v; Data: %v", parsed.DataLength(), parsed.Data())
+	}
+}
+
+func TestOptions(t *testing.T) {
+	p := NewDataPacket()
+	if p.Options() != 0 {
+		t.Errorf("A new packet should have no options set! Was: %08b", p.Options())
+	}
+	p.SetPreviewData(true)
+	p.SetForceSync(true)
+	if p.Options() != OptionPreviewData|OptionForceSync {
+		t.Errorf("Wrong options! Was: %08b", p.Options())
+	}
+	p.SetPreviewData(false)
+	p.SetStreamTerminated(true)
+	if p.Options() != OptionStreamTerminated|OptionForceSync || !p.ForceSync() || p.PreviewData() {
+		t.Errorf("Wrong options! Was: %08b", p.Options())
+	}
+}
+
+func TestChannel(t *testing.T) {
+	p := NewDataPacket()
+	for _, addr := range []int{0, 513} {
+		if err := p.SetChannel(addr, 1); err == nil {
+			t.Errorf("Address %v should not be valid!", addr)
+		}
+		if _, err := p.Channel(addr); err == nil {
+			t.Errorf("Address %v should not be valid!", addr)
+		}
+	}
+	if err := p.SetChannel(1, 10); err != nil {
+		t.Fatal(err)
+	}
+	if value, err := p.Channel(1); err != nil || value != 10 || p.Data()[0] != 10 {
+		t.Errorf("Wrong value for channel 1! Was: %v, %v", value, err)
+	}
+	if value, err := p.Channel(512); err != nil || value != 0 {
+		t.Errorf("Channel 512 should be 0 without data! Was: %v, %v", value, err)
+	}
+	if err := p.SetChannel(512, 20); err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := p.Channel(512); value != 20 || p.DataLength() != 512 || p.Data()[511] != 20 {
+		t.Errorf("Wrong value for channel 512! Was: %v, length %v", value, p.DataLength())
+	}
+	if value, _ := p.Channel(1); value != 10 {
+		t.Errorf("Channel 1 was changed by extending the data! Was: %v", value)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := NewDataPacket()
+	valid.SetUniverse(1)
+	valid.SetData([]byte{1, 2, 3})
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("New packet should be valid: %v", err)
+	}
+	for _, test := range []struct {
+		name   string
+		change func(p *DataPacket)
+	}{
+		{"empty packet", func(p *DataPacket) { *p = DataPacket{} }},
+		{"ACN packet identifier", func(p *DataPacket) { p.data[4] = 'B' }},
+		{"root vector", func(p *DataPacket) { p.data[21] = vectorRootE131Extended }},
+		{"framing vector", func(p *DataPacket) { p.data[43] = 1 }},
+		{"dmp vector", func(p *DataPacket) { p.data[117] = 1 }},
+		{"address and data type", func(p *DataPacket) { p.data[118] = 0xa2 }},
+		{"first property address", func(p *DataPacket) { p.data[120] = 1 }},
+		{"address increment", func(p *DataPacket) { p.data[122] = 2 }},
+		{"priority", func(p *DataPacket) { p.data[OffsetPriority] = MaxPriority + 1 }},
+		{"universe 0", func(p *DataPacket) { p.SetUniverse(0) }},
+		{"universe 64000", func(p *DataPacket) { p.SetUniverse(64000) }},
+		{"sync address 64000", func(p *DataPacket) { p.SetSyncAddress(64000) }},
+		{"property value count", func(p *DataPacket) {
+			p.data = append(p.data, 0)
+			p.length = 125 + 514
+		}},
+		{"per-address priority", func(p *DataPacket) {
+			p.SetDmxStartCode(StartCodePerAddressPriority)
+			p.SetData([]byte{100, MaxPriority + 1})
+		}},
+	} {
+		p := valid.copy()
+		test.change(&p)
+		if err := p.Validate(); err == nil {
+			t.Errorf("Packet with an invalid %v should have been rejected!", test.name)
+		}
+	}
+	//per-address priorities in range are valid
+	p := valid.copy()
+	p.SetDmxStartCode(StartCodePerAddressPriority)
+	p.SetData([]byte{0, 100, MaxPriority})
+	if err := p.Validate(); err != nil {
+		t.Errorf("Packet with valid per-address priorities should be valid: %v", err)
+	}
+}
+
+func TestRaw(t *testing.T) {
+	p := NewDataPacket()
+	p.SetCID([16]byte{1, 2, 3})
+	p.SetSourceName("name")
+	p.SetPriority(150)
+	p.SetSyncAddress(7)
+	p.SetSequence(5)
+	p.SetStreamTerminated(true)
+	p.SetUniverse(0x1234)
+	p.SetDmxStartCode(StartCodeText)
+	p.SetData([]byte{1, 2, 3, 4})
+
+	raw := p.Raw()
+	if len(raw) != OffsetData+4 {
+		t.Fatalf("Raw should contain the whole packet, length was %v", len(raw))
+	}
+	if !bytes.Equal(raw[OffsetCID:OffsetCID+3], []byte{1, 2, 3}) ||
+		string(raw[OffsetSourceName:OffsetSourceName+4]) != "name" ||
+		raw[OffsetPriority] != 150 ||
+		!bytes.Equal(raw[OffsetSyncAddress:OffsetSyncAddress+2], []byte{0, 7}) ||
+		raw[OffsetSequence] != 5 ||
+		raw[OffsetOptions] != OptionStreamTerminated ||
+		!bytes.Equal(raw[OffsetUniverse:OffsetUniverse+2], []byte{0x12, 0x34}) ||
+		raw[OffsetStartCode] != StartCodeText ||
+		!bytes.Equal(raw[OffsetData:], []byte{1, 2, 3, 4}) {
+		t.Errorf("The fields are not at the documented offsets: %v", raw)
+	}
+	raw[OffsetPriority] = 0
+	if p.Priority() != 150 {
+		t.Error("Raw should return a copy!")
+	}
+	if p.RootVector() != vectorRootE131Data || p.FramingVector() != vectorE131DataPacket {
+		t.Errorf("Wrong vectors: root %v, framing %v", p.RootVector(), p.FramingVector())
+	}
+
+	//Raw returns the bytes as received, without recalculating the length fields
+	parsed, err := NewDataPacketRaw(p.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(parsed.Raw(), p.Bytes()) {
+		t.Error("Raw of a parsed packet should return the received bytes!")
+	}
+}
+
+func TestNewDataPacketRawDmpAddressing(t *testing.T) {
+	p := NewDataPacket()
+	p.SetData([]byte{1, 2, 3})
+	valid := p.Bytes()
+	parsed, err := NewDataPacketRaw(valid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.FirstPropertyAddress() != 0 || parsed.AddressIncrement() != 1 {
+		t.Errorf("Wrong DMP addressing! Was: %v, %v", parsed.FirstPropertyAddress(), parsed.AddressIncrement())
+	}
+	for _, test := range []struct {
+		name  string
+		index int
+		value byte
+	}{
+		{"first property address", 120, 1},
+		{"address increment", 122, 2},
+		{"address and data type", 118, 0xa2},
+		{"dmp vector", 117, 0x1},
+	} {
+		raw := append([]byte(nil), valid...)
+		raw[test.index] = test.value
+		if _, err := NewDataPacketRaw(raw); err == nil {
+			t.Errorf("Packet with nonstandard %v should have been rejected!", test.name)
+		}
+	}
+}
+
+//randomDataPacket returns a valid data packet with random values in all fields
+func randomDataPacket(rnd *rand.Rand) DataPacket {
+	p := NewDataPacket()
+	var cid [16]byte
+	rnd.Read(cid[:])
+	p.SetCID(cid)
+	runes := []rune("aZ0 -äö€✓𝄞")
+	name := make([]rune, rnd.Intn(16)) //at most 60 bytes, so that the name always fits
+	for i := range name {
+		name[i] = runes[rnd.Intn(len(runes))]
+	}
+	p.SetSourceName(string(name))
+	p.SetPriority(byte(rnd.Intn(201)))
+	p.SetSyncAddress(uint16(rnd.Intn(64000)))
+	p.SetSequence(byte(rnd.Intn(256)))
+	p.SetPreviewData(rnd.Intn(2) == 0)
+	p.SetStreamTerminated(rnd.Intn(2) == 0)
+	p.SetForceSync(rnd.Intn(2) == 0)
+	p.SetUniverse(uint16(rnd.Intn(63999) + 1))
+	p.SetDmxStartCode(byte(rnd.Intn(256)))
+	data := make([]byte, rnd.Intn(513))
+	rnd.Read(data)
+	p.SetData(data)
+	return p
+}
+
+func TestDataPacketRoundTrip(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		p := randomDataPacket(rnd)
+		raw := p.Bytes()
+		parsed, err := NewDataPacketRaw(raw)
+		if err != nil {
+			t.Fatalf("Valid packet was rejected: %v\n%v", err, p.Hexdump())
+		}
+		if !bytes.Equal(parsed.Bytes(), raw) {
+			t.Fatalf("Packet was changed by parsing!\nBefore: %v\nAfter: %v", p.Hexdump(), parsed.Hexdump())
+		}
+		if parsed.CID() != p.CID() || parsed.SourceName() != p.SourceName() ||
+			parsed.Priority() != p.Priority() || parsed.SyncAddress() != p.SyncAddress() ||
+			parsed.Sequence() != p.Sequence() || parsed.Options() != p.Options() ||
+			parsed.Universe() != p.Universe() || parsed.DmxStartCode() != p.DmxStartCode() ||
+			parsed.DataLength() != p.DataLength() || !bytes.Equal(parsed.Data(), p.Data()) {
+			t.Fatalf("Fields were changed by parsing!\nBefore: %v\nAfter: %v", p, parsed)
+		}
+	}
 }
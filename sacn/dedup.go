@@ -0,0 +1,77 @@
+package sacn
+
+import "time"
+
+//dedupWindow is the count of the last packets that are remembered per source and universe
+const dedupWindow = 16
+
+//dedupMaxAge is the time after which the remembered packets of a source are forgotten
+const dedupMaxAge = time.Second
+
+type dedupKey struct {
+	cid      [16]byte
+	universe uint16
+}
+
+//dedupPacket identifies a packet of a source on a universe. The start code and options are part of
+//it, so that eg a stream terminated packet is not taken for a duplicate of the data before.
+type dedupPacket struct {
+	sequence  byte
+	startCode byte
+	options   byte
+}
+
+//dedupEntry holds the last packets of one source on one universe in a ring buffer
+type dedupEntry struct {
+	packets  [dedupWindow]dedupPacket
+	count    int //the count of valid packets in the ring buffer
+	next     int //the index for the next packet
+	lastSeen time.Time
+}
+
+//dedup detects data packets that were received more than once, eg via multiple network interfaces.
+//A packet is identified by its source, universe, sequence number, start code and options.
+//It is not safe for concurrent use.
+type dedup struct {
+	entries map[dedupKey]*dedupEntry
+}
+
+func newDedup() *dedup {
+	return &dedup{entries: make(map[dedupKey]*dedupEntry)}
+}
+
+//isDuplicate returns true, if the packet was seen within the last packets of the source on the
+//universe. Otherwise the packet is remembered and false is returned. now is the time of the receiver.
+func (d *dedup) isDuplicate(p DataPacket, now time.Time) bool {
+	key := dedupKey{p.CID(), p.Universe()}
+	packet := dedupPacket{p.Sequence(), p.DmxStartCode(), p.Options()}
+	entry, ok := d.entries[key]
+	if !ok {
+		d.removeStale(now)
+		entry = &dedupEntry{}
+		d.entries[key] = entry
+	} else if now.Sub(entry.lastSeen) > dedupMaxAge {
+		*entry = dedupEntry{} //the source may have restarted with the same sequence numbers
+	}
+	entry.lastSeen = now
+	for i := 0; i < entry.count; i++ {
+		if entry.packets[i] == packet {
+			return true
+		}
+	}
+	entry.packets[entry.next] = packet
+	entry.next = (entry.next + 1) % dedupWindow
+	if entry.count < dedupWindow {
+		entry.count++
+	}
+	return false
+}
+
+//removeStale removes all sources that were not seen for longer than dedupMaxAge
+func (d *dedup) removeStale(now time.Time) {
+	for key, entry := range d.entries {
+		if now.Sub(entry.lastSeen) > dedupMaxAge {
+			delete(d.entries, key)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package sacn
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDedup(t *testing.T) {
+	packet := func(cid byte, universe uint16, sequence byte) DataPacket {
+		p := NewDataPacket()
+		p.SetCID([16]byte{cid})
+		p.SetUniverse(universe)
+		p.SetSequence(sequence)
+		return p
+	}
+	d := newDedup()
+	now := time.Unix(1000, 0)
+	if d.isDuplicate(packet(1, 1, 5), now) {
+		t.Error("The first packet is no duplicate!")
+	}
+	if !d.isDuplicate(packet(1, 1, 5), now) {
+		t.Error("The same packet should be a duplicate!")
+	}
+	if d.isDuplicate(packet(2, 1, 5), now) || d.isDuplicate(packet(1, 2, 5), now) ||
+		d.isDuplicate(packet(1, 1, 6), now) {
+		t.Error("Packets of other sources, universes and sequences are no duplicates!")
+	}
+	terminated := packet(1, 1, 5)
+	terminated.SetStreamTerminated(true)
+	prio := packet(1, 1, 5)
+	prio.SetDmxStartCode(StartCodePerAddressPriority)
+	if d.isDuplicate(terminated, now) || d.isDuplicate(prio, now) {
+		t.Error("Packets with other options or start codes are no duplicates!")
+	}
+	//only the last packets are remembered
+	for seq := 7; seq < 7+dedupWindow; seq++ {
+		d.isDuplicate(packet(1, 1, byte(seq)), now)
+	}
+	if d.isDuplicate(packet(1, 1, 5), now) {
+		t.Error("Packets outside of the window should be forgotten!")
+	}
+	//sources that were not seen for a while are forgotten
+	now = now.Add(2 * dedupMaxAge)
+	if d.isDuplicate(packet(1, 1, 5), now) {
+		t.Error("A source that restarted should not be a duplicate!")
+	}
+	d.isDuplicate(packet(3, 1, 1), now)
+	if _, ok := d.entries[dedupKey{[16]byte{2}, 1}]; ok {
+		t.Error("Stale sources should be removed!")
+	}
+}
@@ -0,0 +1,180 @@
+package sacn
+
+import (
+	"fmt"
+	"sort"
+)
+
+const (
+	vectorRootE131Extended              = 8 //VECTOR_ROOT_E131_EXTENDED
+	vectorE131ExtendedDiscovery         = 2 //VECTOR_E131_EXTENDED_DISCOVERY
+	vectorUniverseDiscoveryUniverseList = 1 //VECTOR_UNIVERSE_DISCOVERY_UNIVERSE_LIST
+	discoveryUniverse                   = 64214
+	maxDiscoveryUniverses               = 512 //the maximum count of universes on one page
+)
+
+//DiscoveryPacket is a universe discovery packet. With this packets a source announces all universes
+//it is transmitting on. If there are more than 512 universes, the list is split across multiple pages.
+type DiscoveryPacket struct {
+	data []byte
+}
+
+//NewDiscoveryPacket creates a new DiscoveryPacket with an empty list of universes
+func NewDiscoveryPacket() DiscoveryPacket {
+	p := DiscoveryPacket{make([]byte, 120)}
+	//Set constants: at index [0;16[
+	copy(p.data, constHeader)
+	//Set vectors:
+	copy(p.data[18:], getAsBytes32(vectorRootE131Extended))
+	copy(p.data[40:], getAsBytes32(vectorE131ExtendedDiscovery))
+	copy(p.data[114:], getAsBytes32(vectorUniverseDiscoveryUniverseList))
+	p.setFAL()
+	return p
+}
+
+//NewDiscoveryPacketRaw creates a new DiscoveryPacket based on the given raw bytes. Returns an error,
+//if the bytes are not a valid universe discovery packet.
+func NewDiscoveryPacketRaw(raw []byte) (DiscoveryPacket, error) {
+	var p DiscoveryPacket
+	if len(raw) < 120 || len(raw) > 120+2*maxDiscoveryUniverses {
+		return p, fmt.Errorf("the length of a discovery packet has to be in [120-1144], was %v", len(raw))
+	}
+	if len(raw)%2 != 0 {
+		return p, fmt.Errorf("the universe list has an odd length of %v bytes", len(raw)-120)
+	}
+	if err := checkPacketIdentifier(raw); err != nil {
+		return p, err
+	}
+	if !isDiscoveryPacket(raw) ||
+		getAsUint32(raw[114:118]) != vectorUniverseDiscoveryUniverseList {
+		return p, fmt.Errorf("the vectors of the packet do not belong to a discovery packet")
+	}
+	if err := checkLayers(raw, 16, 38, 112); err != nil {
+		return p, err
+	}
+	p.data = append([]byte(nil), raw...) //make a copy of the slice, we do not want to use a reference
+	return p, nil
+}
+
+//isDiscoveryPacket checks the root and framing vector, if the raw bytes are a discovery packet
+func isDiscoveryPacket(raw []byte) bool {
+	return len(raw) >= 44 &&
+		getAsUint32(raw[18:22]) == vectorRootE131Extended &&
+		getAsUint32(raw[40:44]) == vectorE131ExtendedDiscovery
+}
+
+//Set the FAL values of all layers according to the current length of the packet
+func (d *DiscoveryPacket) setFAL() {
+	length := uint16(len(d.data))
+	rootFAL := calculateFal(length - 16)
+	copy(d.data[16:], rootFAL[:])
+	framingFAL := calculateFal(length - 38)
+	copy(d.data[38:], framingFAL[:])
+	discoveryFAL := calculateFal(length - 112)
+	copy(d.data[112:], discoveryFAL[:])
+}
+
+//SetCID sets the CID unique identifier
+func (d *DiscoveryPacket) SetCID(cid [16]byte) {
+	copy(d.data[22:38], cid[:])
+}
+
+//CID returns the cid of the source that sends this packet
+func (d *DiscoveryPacket) CID() [16]byte {
+	tmpArray := [16]byte{}
+	copy(tmpArray[:], d.data[22:38])
+	return tmpArray
+}
+
+//SetSourceName sets the source name field to the given string value. Returns an error, if the name is
+//not valid UTF-8 or longer than 63 bytes, in which case the packet is not changed.
+func (d *DiscoveryPacket) SetSourceName(s string) error {
+	name, err := encodeSourceName(s)
+	if err != nil {
+		return err
+	}
+	copy(d.data[44:108], name)
+	return nil
+}
+
+//SourceName returns the stored source name. Note that the source name max length is 64!
+func (d *DiscoveryPacket) SourceName() string {
+	return decodeSourceName(d.data[44:108])
+}
+
+//SetPage sets the number of this page, starting with 0
+func (d *DiscoveryPacket) SetPage(page byte) {
+	d.data[118] = page
+}
+
+//Page returns the number of this page, starting with 0
+func (d *DiscoveryPacket) Page() byte {
+	return d.data[118]
+}
+
+//SetLastPage sets the number of the last page of the universe list
+func (d *DiscoveryPacket) SetLastPage(last byte) {
+	d.data[119] = last
+}
+
+//LastPage returns the number of the last page of the universe list
+func (d *DiscoveryPacket) LastPage() byte {
+	return d.data[119]
+}
+
+//SetUniverses sets the universes on this page. The universes are sorted ascending as required by
+//E1.31. Returns an error, if there are more than 512 universes.
+func (d *DiscoveryPacket) SetUniverses(universes []uint16) error {
+	if len(universes) > maxDiscoveryUniverses {
+		return fmt.Errorf("only %v universes are allowed on one page, got %v",
+			maxDiscoveryUniverses, len(universes))
+	}
+	sorted := append([]uint16(nil), universes...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	d.data = d.data[:120]
+	for _, univ := range sorted {
+		d.data = append(d.data, getAsBytes16(univ)...)
+	}
+	d.setFAL()
+	return nil
+}
+
+//Universes returns the universes that are listed on this page
+func (d *DiscoveryPacket) Universes() []uint16 {
+	universes := make([]uint16, 0, (len(d.data)-120)/2)
+	for i := 120; i+1 < len(d.data); i += 2 {
+		universes = append(universes, uint16(getAsUint32(d.data[i:i+2])))
+	}
+	return universes
+}
+
+//Bytes returns the packet as it is send over the network. The returned slice is a copy.
+func (d *DiscoveryPacket) Bytes() []byte {
+	return append([]byte(nil), d.data...)
+}
+
+//buildDiscoveryPackets creates all pages that are needed to announce the given universes
+func buildDiscoveryPackets(cid [16]byte, sourceName string, universes []uint16) []DiscoveryPacket {
+	sorted := append([]uint16(nil), universes...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	lastPage := 0
+	if len(sorted) > 0 {
+		lastPage = (len(sorted) - 1) / maxDiscoveryUniverses
+	}
+	packets := make([]DiscoveryPacket, 0, lastPage+1)
+	for page := 0; page <= lastPage; page++ {
+		end := (page + 1) * maxDiscoveryUniverses
+		if end > len(sorted) {
+			end = len(sorted)
+		}
+		p := NewDiscoveryPacket()
+		p.SetCID(cid)
+		p.SetSourceName(sourceName)
+		p.SetPage(byte(page))
+		p.SetLastPage(byte(lastPage))
+		p.SetUniverses(sorted[page*maxDiscoveryUniverses : end])
+		packets = append(packets, p)
+	}
+	return packets
+}
@@ -0,0 +1,118 @@
+package sacn
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNewDiscoveryPacket(t *testing.T) {
+	p := NewDiscoveryPacket()
+	b := p.Bytes()
+	if len(b) != 120 {
+		t.Errorf("Wrong length! Was: %v; Should've been: %v", len(b), 120)
+	}
+	if !bytes.Equal(b[18:22], []byte{0, 0, 0, 8}) || !bytes.Equal(b[40:44], []byte{0, 0, 0, 2}) ||
+		!bytes.Equal(b[114:118], []byte{0, 0, 0, 1}) {
+		t.Errorf("Wrong vectors! Was: %v", b)
+	}
+	if b[16] != 0x70 || b[17] != 120-16 || b[38] != 0x70 || b[39] != 120-38 ||
+		b[112] != 0x70 || b[113] != 120-112 {
+		t.Errorf("Wrong flags and length fields! Was: %v", b)
+	}
+}
+
+func TestDiscoveryPacketUniverses(t *testing.T) {
+	p := NewDiscoveryPacket()
+	p.SetCID([16]byte{1, 2, 3})
+	p.SetSourceName("test")
+	if err := p.SetUniverses([]uint16{3, 1, 0x1234}); err != nil {
+		t.Fatal(err)
+	}
+	b := p.Bytes()
+	if len(b) != 126 || b[113] != 126-112 {
+		t.Errorf("Wrong length! Was: %v", len(b))
+	}
+	if !bytes.Equal(b[120:], []byte{0, 1, 0, 3, 0x12, 0x34}) {
+		t.Errorf("Universes were not sorted! Was: %v", b[120:])
+	}
+	univs := p.Universes()
+	if len(univs) != 3 || univs[0] != 1 || univs[1] != 3 || univs[2] != 0x1234 {
+		t.Errorf("Wrong universes! Was: %v", univs)
+	}
+	if p.SourceName() != "test" || p.CID() != [16]byte{1, 2, 3} {
+		t.Errorf("Wrong source! Was: %v %v", p.SourceName(), p.CID())
+	}
+	if err := p.SetUniverses(make([]uint16, 513)); err == nil {
+		t.Error("More than 512 universes should be rejected!")
+	}
+}
+
+func TestBuildDiscoveryPackets(t *testing.T) {
+	univs := make([]uint16, 0, 1100)
+	for i := 1100; i > 0; i-- {
+		univs = append(univs, uint16(i))
+	}
+	packets := buildDiscoveryPackets([16]byte{1}, "test", univs)
+	if len(packets) != 3 {
+		t.Fatalf("Wrong number of pages! Was: %v", len(packets))
+	}
+	for i, p := range packets {
+		if p.Page() != byte(i) || p.LastPage() != 2 {
+			t.Errorf("Wrong page numbers! Was: %v/%v", p.Page(), p.LastPage())
+		}
+	}
+	if len(packets[0].Universes()) != 512 || len(packets[2].Universes()) != 76 {
+		t.Errorf("Wrong page sizes! Was: %v, %v", len(packets[0].Universes()), len(packets[2].Universes()))
+	}
+	if packets[0].Universes()[0] != 1 || packets[1].Universes()[0] != 513 {
+		t.Error("Universes were not sorted across pages!")
+	}
+	if len(packets[0].Bytes()) != 1144 {
+		t.Errorf("A full page should be 1144 bytes long, was %v", len(packets[0].Bytes()))
+	}
+
+	packets = buildDiscoveryPackets([16]byte{1}, "test", nil)
+	if len(packets) != 1 || len(packets[0].Universes()) != 0 {
+		t.Error("Without universes there should be a single empty page!")
+	}
+}
+
+func TestNewDiscoveryPacketRaw(t *testing.T) {
+	p := NewDiscoveryPacket()
+	p.SetCID([16]byte{4, 5})
+	p.SetSourceName("raw")
+	p.SetPage(1)
+	p.SetLastPage(2)
+	p.SetUniverses([]uint16{7, 8})
+	parsed, err := NewDiscoveryPacketRaw(p.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.CID() != p.CID() || parsed.SourceName() != "raw" || parsed.Page() != 1 ||
+		parsed.LastPage() != 2 || len(parsed.Universes()) != 2 {
+		t.Error("Parsed packet is not equal to the build one!")
+	}
+	if err := p.SetSourceName(string(make([]byte, 64))); err == nil || p.SourceName() != "raw" {
+		t.Error("Too long source name should be rejected!")
+	}
+	if _, err := NewDiscoveryPacketRaw(p.Bytes()[:119]); err == nil {
+		t.Error("Too short packet should be rejected!")
+	}
+	if _, err := NewDiscoveryPacketRaw(p.Bytes()[:123]); err == nil {
+		t.Error("Packet with an odd universe list should be rejected!")
+	}
+	if _, err := NewDiscoveryPacketRaw(p.Bytes()[:122]); err == nil {
+		t.Error("Packet whose length does not match its length fields should be rejected!")
+	}
+	for _, index := range []int{4, 16, 38, 112, 113} {
+		raw := p.Bytes()
+		raw[index]++
+		if _, err := NewDiscoveryPacketRaw(raw); err == nil {
+			t.Errorf("Packet with a changed byte %v should be rejected!", index)
+		}
+	}
+	data := NewDataPacket()
+	if _, err := NewDiscoveryPacketRaw(data.Bytes()); err == nil {
+		t.Error("Data packet should be rejected!")
+	}
+}
@@ -7,15 +7,29 @@ http://opendmx.net/index.php/Open_Lighting_Architecture project.
 Receiving
 
 The simplest way to receive sACN packets is to use `sacn.NewReceiverSocket`.
+Changed data is reported to the callback of `receiver.SetOnChangeCallback` or to the handler of a single
+universe that is registered via `receiver.OnData(<universe>, <handler>)`. Timeouts and terminated
+streams are reported as `sacn.ReceiveError` to the handler of `receiver.OnError`.
+For one-shot reads, `receiver.WaitForData(<ctx>, <universe>)` blocks until the next frame arrives.
+To read channels at any time, `sacn.NewUniverse(<receiver>, <universe>)` keeps the current data.
+Parse errors, failed multicast joins and timeouts can be logged via `receiver.SetLogger`, eg with
+`sacn.SlogLogger(<*slog.Logger>)`.
 
 The receiver checks for out-of-order packets (inspecting the sequence number) and sorts for priority.
-Synchronization must be implemented in the callers program, but currently there is no way to receive
-the sACN sync-packets. This feature may come in a future version.
+If multiple sources send with the same priority, `receiver.SetArbitrationPolicy` decides which one is used.
+If the source with the highest priority stops sending, a backup source with a lower priority takes over
+after the timeout or after the time that is set with `receiver.SetPriorityTimeout`.
+Packets with a sync address are held back until the sync packet for this address arrives. If no sync
+packet arrives within the timeout, the held data is processed anyway. Note that you have to join the
+sync universe, if the sync packets are send via multicast.
 
 This `sacn.ReceiverSocket` can use multicast groups to receive its data. Unicast packets that are received
-are also processed like the normal unicast receiver. Depending on your operating system, you might can
-provide `nil` as an interface, sometimes you have to use a dedicated interface, to get multicast working.
-Windows needs an interface and Linux generally not.
+are also processed like the normal unicast receiver. To use multicast, you have to provide the
+interface that should join the multicast groups. If `nil` is provided, only unicast is received and
+`receiver.JoinUniverse(<universe>)` returns an error.
+For sACN over IPv6 use `sacn.NewReceiverSocketIPv6`, which joins the ff18::83:0:0:<universe> groups.
+To receive on multiple networks at once, use `sacn.NewMultiReceiver` with one binding per interface.
+Packets that arrive on more than one network are only handled once.
 
 Note that the network infrastructure has to be multicast ready and that on some networks the delay of
 packets will increase. Also the packet loss can be higher if multicast is chosen
@@ -25,13 +39,26 @@ are only transmitting every 2 seconds (like grandMA2 consoles).
 Transmitting
 
 To transmitt DMX data, you have to initialize a `Transmitter` object. This handles all the protocol
-specific actions (currently not all). You can activate universes, if you wish to send out data.
+specific actions (currently not all). Every source needs a CID as unique identifier: create a random one
+with `sacn.NewCID()` once and keep it, or parse a stored one with `sacn.CIDFromString(<uuid>)`.
+You can activate universes, if you wish to send out data.
 Then you can use a channel for 512-byte arrays to transmitt them over the network.
+Changed data is send out with at most 44 packets per second (see `transmitter.SetFrameRate`) and the
+last data is repeated every second as keep alive. To stop a universe, close its channel or call
+`transmitter.Deactivate(<universe>)`.
+To synchronize multiple universes, set a sync universe via `transmitter.SetSyncUniverse` and call
+`transmitter.SendSync(<sync universe>)` after the data of all universes was sent.
+While universes are active, the transmitter announces them every 10 seconds with universe discovery
+packets via multicast.
 
 There are two different types of addressing the receiver: unicast and multicast.
 When using multicast, note that you have to provide a bind address on some operating systems
 (eg Windows). You can use both at the same time and any number of unicast addresses.
 To set wether multicast should be used, call `transmitter.SetMulticast(<universe>, <bool>)`.
+Multicast packets are send with a TTL of 1, so they do not cross routers. For routed multicast setups
+increase the TTL via `transmitter.SetMulticastTTL(<ttl>)`.
+On hosts with multiple network interfaces, choose the interface for multicast via
+`transmitter.SetOutputInterface(<interface>)`.
 You can set multiple unicast destinations as slice via
 `transmitter.SetDestinations(<universe>, <[]string>)`.
 Note that any existing destinations will be overwritten. If you want to append a destination, you
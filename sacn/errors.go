@@ -0,0 +1,60 @@
+package sacn
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+//Errors of the receiver. Use errors.Is to check for them, they are wrapped in a ReceiveError if they
+//belong to a universe.
+var (
+	//ErrNoMulticastInterface is returned if multicast is used on a receiver without an interface
+	ErrNoMulticastInterface = errors.New("the receiver has no multicast interface, so multicast can not be used")
+	//ErrTimeout occurs if no data was received on a universe within the timeout
+	ErrTimeout = errors.New("timeout")
+	//ErrStreamTerminated occurs if the source of a universe has terminated its stream
+	ErrStreamTerminated = errors.New("stream terminated")
+	//ErrSourcesExceeded occurs with the ErrorOnConflict policy, if another source sends on a universe
+	//with the same priority as the used source. The CIDs are the used and the conflicting source.
+	ErrSourcesExceeded = errors.New("sources exceeded")
+	//ErrSyncLost occurs if data was held for a sync address, but no sync packet arrived within the
+	//timeout. The held data is processed anyway. It is reported with the sync address as universe.
+	ErrSyncLost = errors.New("synchronization lost")
+	//ErrInterfaceDown occurs if the socket of the receiver can not be read anymore, eg because the
+	//network interface is down. It is reported with universe 0.
+	ErrInterfaceDown = errors.New("the socket can not be read, the interface may be down")
+)
+
+//ReceiveError is an error that occurred on a universe of the receiver. Err is one of the errors above
+//and CIDs contains the sources that were involved, if there are any.
+type ReceiveError struct {
+	Universe uint16
+	CIDs     [][16]byte
+	Err      error
+}
+
+func (e ReceiveError) Error() string {
+	return fmt.Sprintf("universe %v: %v", e.Universe, e.Err)
+}
+
+//Unwrap returns the underlying error, so that errors.Is can be used
+func (e ReceiveError) Unwrap() error {
+	return e.Err
+}
+
+//RangeError is returned by functions that handle multiple universes, eg JoinUniverseRange, if some
+//universes failed. All other universes were successful.
+type RangeError struct {
+	Failed map[uint16]error //the error of every failed universe
+}
+
+func (e *RangeError) Error() string {
+	universes := make([]int, 0, len(e.Failed))
+	for univ := range e.Failed {
+		universes = append(universes, int(univ))
+	}
+	sort.Ints(universes)
+	return fmt.Sprintf("%v universes failed %v, first error: %v", len(universes), universes,
+		e.Failed[uint16(universes[0])])
+}
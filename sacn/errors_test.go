@@ -0,0 +1,20 @@
+package sacn
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestReceiveError(t *testing.T) {
+	var err error = ReceiveError{Universe: 7, Err: ErrTimeout}
+	if !errors.Is(err, ErrTimeout) || errors.Is(err, ErrStreamTerminated) {
+		t.Errorf("errors.Is does not work for ReceiveError: %v", err)
+	}
+	if err.Error() != "universe 7: timeout" {
+		t.Errorf("Wrong error message! Was: %q", err.Error())
+	}
+	var recvErr ReceiveError
+	if !errors.As(err, &recvErr) || recvErr.Universe != 7 {
+		t.Errorf("errors.As does not work for ReceiveError: %v", err)
+	}
+}
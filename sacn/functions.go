@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math"
 	"net"
+	"unicode/utf8"
 )
 
 //CalculateFal : Calculates the two bytes of a FlagsAndLength field of a sACN packet
@@ -13,6 +14,11 @@ func calculateFal(length uint16) [2]byte {
 		byte(0xFF & length)}
 }
 
+//falLength returns the length that is stored in the two bytes of a FlagsAndLength field
+func falLength(fal []byte) int {
+	return int(fal[0]&0x0F)<<8 | int(fal[1])
+}
+
 func getAsBytes32(i uint32) []byte {
 	return []byte{byte(i >> 24), byte(i >> 16), byte(i >> 8), byte(i & 0xFF)}
 }
@@ -20,6 +26,54 @@ func getAsBytes16(i uint16) []byte {
 	return []byte{byte(i >> 8), byte(i & 0xFF)}
 }
 
+//maxSourceNameLength is the maximum length of a source name in bytes. The field has 64 bytes, but the
+//name has to be null-terminated.
+const maxSourceNameLength = 63
+
+//checkSourceName returns an error, if the name is not valid UTF-8 or does not fit into the source
+//name field
+func checkSourceName(s string) error {
+	if !utf8.ValidString(s) {
+		return fmt.Errorf("the source name %q is not valid UTF-8", s)
+	}
+	if len(s) > maxSourceNameLength {
+		return fmt.Errorf("the source name can have at most %v bytes, was %v", maxSourceNameLength, len(s))
+	}
+	return nil
+}
+
+//encodeSourceName returns the 64 bytes of the source name field for the given name, which is padded
+//with null bytes. Returns an error, if the name is invalid, see checkSourceName.
+func encodeSourceName(s string) ([]byte, error) {
+	if err := checkSourceName(s); err != nil {
+		return nil, err
+	}
+	b := make([]byte, 64)
+	copy(b, s)
+	return b, nil
+}
+
+//decodeSourceName returns the name that is stored in the given source name field. The name ends at
+//the first null byte or at the end of the field. Invalid UTF-8 is replaced with the replacement
+//character U+FFFD.
+func decodeSourceName(b []byte) string {
+	i := 0 //the ending index for the string, because it is 0 terminated
+	for i < len(b) && b[i] != 0 {
+		i++
+	}
+	name := b[:i]
+	if utf8.Valid(name) {
+		return string(name)
+	}
+	runes := make([]rune, 0, len(name))
+	for len(name) > 0 {
+		r, size := utf8.DecodeRune(name) //returns utf8.RuneError for invalid bytes
+		runes = append(runes, r)
+		name = name[size:]
+	}
+	return string(runes)
+}
+
 func getAsUint32(arr []byte) uint32 {
 	value := uint32(0)
 	for i := range arr {
@@ -29,6 +83,42 @@ func getAsUint32(arr []byte) uint32 {
 	return value
 }
 
+//checkUniverse returns an error if the universe is not in the valid range of [1-63999]
+func checkUniverse(universe uint16) error {
+	if universe < 1 || universe > 63999 {
+		return fmt.Errorf("the universe %v is not in the range [1-63999]", universe)
+	}
+	return nil
+}
+
+//checkUniverseRange checks that first and last are valid universes and that first is not after last
+func checkUniverseRange(first, last uint16) error {
+	if err := checkUniverse(first); err != nil {
+		return err
+	}
+	if err := checkUniverse(last); err != nil {
+		return err
+	}
+	if first > last {
+		return fmt.Errorf("the first universe %v is after the last universe %v", first, last)
+	}
+	return nil
+}
+
+//forUniverseRange calls f for every universe of the range. The errors are collected in a RangeError.
+func forUniverseRange(first, last uint16, f func(universe uint16) error) error {
+	failed := make(map[uint16]error)
+	for univ := int(first); univ <= int(last); univ++ {
+		if err := f(uint16(univ)); err != nil {
+			failed[uint16(univ)] = err
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return &RangeError{Failed: failed}
+}
+
 func calcMulticastAddr(universe uint16) string {
 	byt := getAsBytes16(universe)
 	return fmt.Sprintf("239.255.%v.%v", byt[0], byt[1])
@@ -39,9 +129,64 @@ func calcMulticastUDPAddr(universe uint16) *net.UDPAddr {
 	return addr
 }
 
+//UniverseToMulticastIP returns the IPv4 multicast address of the universe: 239.255.<high byte>.<low byte>.
+//Returns nil, if the universe is not in the range [1-63999] and not the universe discovery universe 64214.
+func UniverseToMulticastIP(universe uint16) net.IP {
+	if checkUniverse(universe) != nil && universe != discoveryUniverse {
+		return nil
+	}
+	byt := getAsBytes16(universe)
+	return net.IPv4(239, 255, byt[0], byt[1])
+}
+
+//MulticastIPToUniverse returns the universe of the IPv4 multicast address. Returns false, if the address
+//is no sACN multicast address of a valid universe.
+func MulticastIPToUniverse(ip net.IP) (uint16, bool) {
+	ip4 := ip.To4()
+	if ip4 == nil || ip4[0] != 239 || ip4[1] != 255 {
+		return 0, false
+	}
+	universe := uint16(ip4[2])<<8 | uint16(ip4[3])
+	if checkUniverse(universe) != nil && universe != discoveryUniverse {
+		return 0, false
+	}
+	return universe, true
+}
+
+//calcMulticastIPv6 returns the IPv6 multicast address of the universe: ff18::83:0:0:<universe>
+func calcMulticastIPv6(universe uint16) net.IP {
+	ip := make(net.IP, net.IPv6len)
+	ip[0], ip[1] = 0xff, 0x18
+	ip[9] = 0x83 //the group ff18::83:0:0:0/96, the universe is in the last 32 bits
+	copy(ip[14:], getAsBytes16(universe))
+	return ip
+}
+
+//multicastGroupUniverse returns the universe of the IPv4 or IPv6 multicast-group. Returns false, if the
+//address is no sACN multicast-group.
+func multicastGroupUniverse(ip net.IP) (uint16, bool) {
+	if ip.To4() != nil {
+		return MulticastIPToUniverse(ip)
+	}
+	if len(ip) != net.IPv6len {
+		return 0, false
+	}
+	universe := uint16(ip[14])<<8 | uint16(ip[15])
+	if !ip.Equal(calcMulticastIPv6(universe)) {
+		return 0, false
+	}
+	return universe, true
+}
+
+func calcMulticastUDPAddrIPv6(universe uint16) *net.UDPAddr {
+	return &net.UDPAddr{IP: calcMulticastIPv6(universe), Port: 5568}
+}
+
+//checkSequ implements the sequence numbering algorithm of E1.31 section 6.7.2: the packet is out of
+//order, if the difference to the last sequence number in signed 8-bit arithmetic is in ]-20;0]
 func checkSequ(old, new byte) bool {
-	//calculate in int
-	tmp := int(new) - int(old)
+	//calculate in int8, so that the wraparound from 255 to 0 is handled correctly
+	tmp := int8(new - old)
 	if tmp <= 0 && tmp > -20 {
 		return false
 	}
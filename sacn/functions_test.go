@@ -2,6 +2,8 @@ package sacn
 
 import (
 	"bytes"
+	"errors"
+	"net"
 	"testing"
 )
 
@@ -66,4 +68,140 @@ func TestCheckSequ(t *testing.T) {
 	if checkSequ(255, 250) {
 		t.Error("should not be allowed!")
 	}
+	tests := []struct {
+		old, new byte
+		want     bool
+	}{
+		{50, 50, false}, //same sequence
+		{50, 51, true},  //+1
+		{50, 31, false}, //-19
+		{50, 30, true},  //-20
+		{50, 29, true},  //-21
+		{250, 5, true},  //wraparound: +11
+		{5, 250, false}, //wraparound: -11
+		{10, 246, true}, //wraparound: -20
+		{255, 0, true},  //wraparound: +1
+		{0, 255, false}, //wraparound: -1
+		{0, 128, true},  //-128
+	}
+	for _, tt := range tests {
+		if got := checkSequ(tt.old, tt.new); got != tt.want {
+			t.Errorf("checkSequ(%v, %v) was %v; Should've been: %v", tt.old, tt.new, got, tt.want)
+		}
+	}
+}
+
+func TestCheckUniverse(t *testing.T) {
+	for _, univ := range []uint16{1, 100, 63999} {
+		if err := checkUniverse(univ); err != nil {
+			t.Errorf("Universe %v should be valid, got: %v", univ, err)
+		}
+	}
+	for _, univ := range []uint16{0, 64000, 65535} {
+		if err := checkUniverse(univ); err == nil {
+			t.Errorf("Universe %v should not be valid!", univ)
+		}
+	}
+}
+
+func TestFalLength(t *testing.T) {
+	fal := calculateFal(0x123)
+	if falLength(fal[:]) != 0x123 {
+		t.Errorf("Wrong output! Was: %v; Should've been: %v", falLength(fal[:]), 0x123)
+	}
+}
+
+func TestCalcMulticastIPv6(t *testing.T) {
+	out := calcMulticastIPv6(0x0102)
+	if out.String() != "ff18::83:0:0:102" || !out.IsMulticast() {
+		t.Errorf("Wrong output! Was: %v; Should've been: %v", out, "ff18::83:0:0:102")
+	}
+	//the universe is inside of the ff18::83:0:0:0/96 group of E1.31
+	if _, prefix, _ := net.ParseCIDR("ff18::83:0:0:0/96"); !prefix.Contains(calcMulticastIPv6(63999)) {
+		t.Errorf("%v is not in %v!", calcMulticastIPv6(63999), prefix)
+	}
+	addr := calcMulticastUDPAddrIPv6(1)
+	if addr.Port != 5568 || addr.IP.String() != "ff18::83:0:0:1" {
+		t.Errorf("Wrong output! Was: %v", addr)
+	}
+}
+
+func TestMulticastGroupUniverse(t *testing.T) {
+	for _, ip := range []net.IP{UniverseToMulticastIP(0x0102), calcMulticastIPv6(0x0102)} {
+		if universe, ok := multicastGroupUniverse(ip); !ok || universe != 0x0102 {
+			t.Errorf("Wrong universe of %v: %v %v", ip, universe, ok)
+		}
+	}
+	for _, ip := range []net.IP{net.IPv4(239, 255, 255, 255), net.ParseIP("ff18::84:0:0:102"), net.ParseIP("ff02::1"), nil} {
+		if _, ok := multicastGroupUniverse(ip); ok {
+			t.Errorf("%v should be no sACN multicast-group!", ip)
+		}
+	}
+}
+
+func TestCheckUniverseRange(t *testing.T) {
+	for _, r := range [][2]uint16{{1, 1}, {1, 64}, {63999, 63999}} {
+		if err := checkUniverseRange(r[0], r[1]); err != nil {
+			t.Errorf("Range %v should be valid, got: %v", r, err)
+		}
+	}
+	for _, r := range [][2]uint16{{0, 5}, {5, 64000}, {10, 9}} {
+		if err := checkUniverseRange(r[0], r[1]); err == nil {
+			t.Errorf("Range %v should not be valid!", r)
+		}
+	}
+}
+
+func TestForUniverseRange(t *testing.T) {
+	var called []uint16
+	err := forUniverseRange(63997, 63999, func(univ uint16) error {
+		called = append(called, univ)
+		if univ == 63998 {
+			return errors.New("failed")
+		}
+		return nil
+	})
+	if len(called) != 3 || called[0] != 63997 || called[2] != 63999 {
+		t.Errorf("Wrong universes were called: %v", called)
+	}
+	rangeErr, ok := err.(*RangeError)
+	if !ok || len(rangeErr.Failed) != 1 || rangeErr.Failed[63998] == nil {
+		t.Fatalf("Wrong error! Was: %v", err)
+	}
+	if err.Error() != "1 universes failed [63998], first error: failed" {
+		t.Errorf("Wrong error message! Was: %q", err.Error())
+	}
+}
+
+func TestUniverseToMulticastIP(t *testing.T) {
+	tests := []struct {
+		universe uint16
+		ip       string
+	}{
+		{1, "239.255.0.1"},
+		{255, "239.255.0.255"},
+		{256, "239.255.1.0"},
+		{63999, "239.255.249.255"},
+		{64214, "239.255.250.214"},
+	}
+	for _, tt := range tests {
+		ip := UniverseToMulticastIP(tt.universe)
+		if ip.String() != tt.ip {
+			t.Errorf("Universe %v: wrong ip! Was: %v; Should've been: %v", tt.universe, ip, tt.ip)
+		}
+		if univ, ok := MulticastIPToUniverse(ip); !ok || univ != tt.universe {
+			t.Errorf("Round trip of %v failed! Was: %v, %v", tt.universe, univ, ok)
+		}
+	}
+	for _, univ := range []uint16{0, 64000, 65535} {
+		if ip := UniverseToMulticastIP(univ); ip != nil {
+			t.Errorf("Universe %v should not have an ip! Was: %v", univ, ip)
+		}
+	}
+	for _, ip := range []net.IP{nil, net.IPv4(239, 255, 0, 0), net.IPv4(239, 254, 0, 1), net.IPv4(239, 255, 250, 0),
+		net.ParseIP("ff18::83:0:0:1")} {
+		if univ, ok := MulticastIPToUniverse(ip); ok {
+			t.Errorf("%v should not be a sACN multicast address! Was: %v", ip, univ)
+		}
+	}
 }
@@ -0,0 +1,95 @@
+package sacn
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+//dataPacketJSON is the JSON representation of a DataPacket
+type dataPacketJSON struct {
+	Universe    uint16 `json:"universe"`
+	Priority    byte   `json:"priority"`
+	Sequence    byte   `json:"sequence"`
+	SourceName  string `json:"sourceName"`
+	CID         string `json:"cid"`
+	SyncAddress uint16 `json:"syncAddress"`
+	Options     byte   `json:"options"`
+	StartCode   byte   `json:"startCode"`
+	Data        []int  `json:"data"` //the data as array of numbers instead of base64
+}
+
+/*
+MarshalJSON returns the packet as JSON object, eg for monitoring web services:
+
+	{"universe":1,"priority":100,"sequence":5,"sourceName":"name",
+	 "cid":"01020300-0000-0000-0000-000000000000","syncAddress":0,"options":0,"startCode":0,
+	 "data":[255,0,12]}
+
+The data is an array with one number per DMX slot. See UnmarshalJSON for the reverse.
+*/
+func (d DataPacket) MarshalJSON() ([]byte, error) {
+	if len(d.data) < 126 {
+		return nil, fmt.Errorf("the packet is empty, use NewDataPacket to create a packet")
+	}
+	data := d.Data()
+	values := make([]int, len(data))
+	for i, value := range data {
+		values[i] = int(value)
+	}
+	return json.Marshal(dataPacketJSON{
+		Universe:    d.Universe(),
+		Priority:    d.Priority(),
+		Sequence:    d.Sequence(),
+		SourceName:  d.SourceName(),
+		CID:         CIDString(d.CID()),
+		SyncAddress: d.SyncAddress(),
+		Options:     d.Options(),
+		StartCode:   d.DmxStartCode(),
+		Data:        values,
+	})
+}
+
+//UnmarshalJSON sets the packet to the JSON object that was created by MarshalJSON, eg to replay
+//recorded data. Missing fields have their default value, but the universe is required. Like for
+//SetData, data with an odd length is padded with a 0. Returns an error, if a field is invalid or the
+//packet is not valid (see Validate), in which case the packet is not changed.
+func (d *DataPacket) UnmarshalJSON(b []byte) error {
+	v := dataPacketJSON{Priority: DefaultPriority}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	p := NewDataPacket()
+	if v.CID != "" {
+		cid, err := CIDFromString(v.CID)
+		if err != nil {
+			return err
+		}
+		p.SetCID(cid)
+	}
+	if err := p.SetPriority(v.Priority); err != nil {
+		return err
+	}
+	if err := p.SetSourceName(v.SourceName); err != nil {
+		return err
+	}
+	data := make([]byte, len(v.Data))
+	for i, value := range v.Data {
+		if value < 0 || value > 255 {
+			return fmt.Errorf("the value of slot %v has to be in [0-255], was %v", i+1, value)
+		}
+		data[i] = byte(value)
+	}
+	if err := p.SetData(data); err != nil {
+		return err
+	}
+	p.SetUniverse(v.Universe)
+	p.SetSequence(v.Sequence)
+	p.SetSyncAddress(v.SyncAddress)
+	p.data[112] = v.Options
+	p.SetDmxStartCode(v.StartCode)
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	*d = p
+	return nil
+}
@@ -0,0 +1,62 @@
+package sacn
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestDataPacketJSON(t *testing.T) {
+	p := NewDataPacket()
+	p.SetUniverse(7)
+	p.SetPriority(150)
+	p.SetSequence(42)
+	p.SetSourceName("Pult 光")
+	p.SetCID([16]byte{1, 2, 3})
+	p.SetSyncAddress(9)
+	p.SetForceSync(true)
+	p.SetDmxStartCode(StartCodeText)
+	p.SetData([]byte{255, 0, 12, 7})
+
+	b, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"cid":"01020300-0000-0000-0000-000000000000"`) ||
+		!strings.Contains(string(b), `"data":[255,0,12,7]`) {
+		t.Errorf("Wrong JSON! Was: %s", b)
+	}
+	var parsed DataPacket
+	if err := json.Unmarshal(b, &parsed); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(parsed.Bytes(), p.Bytes()) {
+		t.Errorf("Round trip changed the packet!\nWas:            %v\nShould've been: %v", &parsed, &p)
+	}
+
+	//missing fields have their default value
+	if err := json.Unmarshal([]byte(`{"universe":1}`), &parsed); err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Universe() != 1 || parsed.Priority() != DefaultPriority || parsed.DataLength() != 0 {
+		t.Errorf("Wrong defaults! Was: %v", &parsed)
+	}
+	for _, invalid := range []string{
+		`{}`,
+		`{"universe":0}`,
+		`{"universe":64000}`,
+		`{"universe":1,"syncAddress":64000}`,
+		`{"universe":1,"priority":201}`,
+		`{"universe":1,"cid":"no cid"}`,
+		`{"universe":1,"data":[256]}`,
+		`{"universe":1,"sourceName":"` + strings.Repeat("a", 64) + `"}`,
+	} {
+		if err := json.Unmarshal([]byte(invalid), &parsed); err == nil {
+			t.Errorf("Invalid JSON %v should have been rejected!", invalid)
+		}
+	}
+	if parsed.Universe() != 1 {
+		t.Error("Rejected JSON should not change the packet!")
+	}
+}
@@ -0,0 +1,18 @@
+package sacn
+
+//Logger gets informed by the receiver about events that are not reported in another way, eg packets
+//that could not be parsed or multicast-groups that could not be joined. The arguments after the
+//message are alternating keys and values, so a *slog.Logger can be used directly and other logging
+//libraries only need a small adapter, see ReceiverSocket.SetLogger.
+//The methods are called from the listener of the receiver, so they have to return quickly and must
+//not call methods of the receiver.
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Info(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+}
+
+//loggerHolder wraps the Logger, so that an atomic.Value always stores the same type
+type loggerHolder struct {
+	logger Logger
+}
@@ -0,0 +1,77 @@
+package sacn
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+//recordingLogger stores all log messages with their level and arguments
+type recordingLogger struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+func (l *recordingLogger) add(level, msg string, args []interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.messages = append(l.messages, fmt.Sprint(level, " ", msg, " ", args))
+}
+
+func (l *recordingLogger) Debug(msg string, args ...interface{}) { l.add("debug", msg, args) }
+func (l *recordingLogger) Info(msg string, args ...interface{})  { l.add("info", msg, args) }
+func (l *recordingLogger) Warn(msg string, args ...interface{})  { l.add("warn", msg, args) }
+
+func (l *recordingLogger) contains(prefix string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, msg := range l.messages {
+		if strings.HasPrefix(msg, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestLogger(t *testing.T) {
+	p := NewDataPacket()
+	p.SetUniverse(3)
+	p.SetData([]byte{1})
+	conn := &fakeConn{reads: []fakeRead{{data: []byte("no sACN packet")}, {data: p.Bytes()}}}
+
+	r := newTestReceiver()
+	r.socket = conn
+	r.SetTimeout(20 * time.Millisecond)
+	logger := &recordingLogger{}
+	r.SetLogger(logger)
+	r.Start()
+	defer r.Close()
+
+	for _, prefix := range []string{"debug invalid packet [addr 127.0.0.1:5568 err ",
+		"info universe timed out [universe 3 cid 00000000-0000-0000-0000-000000000000]"} {
+		for start := time.Now(); !logger.contains(prefix); time.Sleep(time.Millisecond) {
+			if time.Since(start) > time.Second {
+				t.Fatalf("%q was not logged! Logged: %q", prefix, logger.messages)
+			}
+		}
+	}
+
+	r.SetLogger(nil)
+	if r.log() != nil {
+		t.Error("The logger was not removed!")
+	}
+}
+
+func TestLoggerNoAllocs(t *testing.T) {
+	r := newTestReceiver()
+	addr := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 5568}
+	allocs := testing.AllocsPerRun(100, func() {
+		r.parseError(addr, errPacketTooLarge)
+	})
+	if allocs != 0 {
+		t.Errorf("Logging without a logger should not allocate! Allocations: %v", allocs)
+	}
+}
@@ -0,0 +1,120 @@
+package sacn
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"time"
+)
+
+//loopbackMulticastTimeout is the time that CheckLoopback waits for a multicast frame, before it falls
+//back to unicast
+const loopbackMulticastTimeout = time.Second
+
+/*
+CheckLoopback sends frames from a Transmitter to a ReceiverSocket on this host and checks that they
+arrive intact, in order and that the change of the data is detected. This can be used to validate
+the environment, eg that the sACN port is free and the firewall lets the packets through.
+
+Multicast is tried first on the first interface that supports it. If no frame arrives within a
+second, eg because multicast is restricted, unicast to 127.0.0.1 is used instead. The returned bool
+is true, if the frames were received via multicast. An error is returned, if no frames arrived
+before the context is done or if they were not received correctly.
+Note that the receiver binds the sACN port, so no other receiver may use it exclusively.
+*/
+func CheckLoopback(ctx context.Context, universe uint16) (multicast bool, err error) {
+	if err := checkUniverse(universe); err != nil {
+		return false, err
+	}
+	trans, err := NewTransmitter("", NewCID(), "go-sacn loopback check")
+	if err != nil {
+		return false, err
+	}
+	ifi := loopbackInterface(trans)
+	recv, err := NewReceiverSocket("", ifi)
+	if err != nil {
+		return false, err
+	}
+	defer recv.Close()
+	frames := make(chan DataPacket, 10)
+	recv.OnData(universe, func(p DataPacket) {
+		select {
+		case frames <- p:
+		default:
+		}
+	})
+	recv.Start()
+
+	if ifi != nil && recv.JoinUniverse(universe) == nil {
+		trans.SetMulticast(universe, true)
+		multicastCtx, cancel := context.WithTimeout(ctx, loopbackMulticastTimeout)
+		err = checkRoundTrip(multicastCtx, trans, universe, frames)
+		cancel()
+		if err == nil {
+			return true, nil
+		}
+		trans.SetMulticast(universe, false)
+	}
+	trans.SetDestinations(universe, []string{"127.0.0.1"})
+	return false, checkRoundTrip(ctx, trans, universe, frames)
+}
+
+//loopbackInterface returns the first interface that can send and receive multicast, or nil if there
+//is none. The interface is set as output interface of the transmitter.
+func loopbackInterface(trans *Transmitter) *net.Interface {
+	ifis, err := net.Interfaces()
+	if err != nil {
+		return nil
+	}
+	for i := range ifis {
+		if ifis[i].Flags&net.FlagUp == 0 || ifis[i].Flags&net.FlagMulticast == 0 {
+			continue
+		}
+		if trans.SetOutputInterface(&ifis[i]) == nil {
+			return &ifis[i]
+		}
+	}
+	return nil
+}
+
+//checkRoundTrip activates the universe and sends a test pattern. The initial zero frame and the
+//test pattern have to be delivered in this order.
+func checkRoundTrip(ctx context.Context, trans *Transmitter, universe uint16, frames <-chan DataPacket) error {
+	ch, err := trans.Activate(universe)
+	if err != nil {
+		return err
+	}
+	defer trans.Deactivate(universe)
+
+	var pattern [512]byte
+	for i := range pattern {
+		pattern[i] = byte(i)
+	}
+	var first DataPacket
+	select {
+	case first = <-frames:
+	case <-ctx.Done():
+		return fmt.Errorf("no frame was received on universe %v: %v", universe, ctx.Err())
+	}
+	select {
+	case ch <- pattern:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	for {
+		select {
+		case p := <-frames:
+			if !bytes.Equal(p.Data(), pattern[:]) {
+				continue //eg a late frame of the multicast attempt
+			}
+			if !checkSequ(first.Sequence(), p.Sequence()) {
+				return fmt.Errorf("the frames were received out of order, sequence %v after %v",
+					p.Sequence(), first.Sequence())
+			}
+			return nil
+		case <-ctx.Done():
+			return fmt.Errorf("the changed data was not received on universe %v: %v", universe, ctx.Err())
+		}
+	}
+}
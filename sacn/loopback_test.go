@@ -0,0 +1,33 @@
+package sacn
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func ExampleCheckLoopback() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	multicast, err := CheckLoopback(ctx, 1)
+	if err != nil {
+		fmt.Println("sACN does not work on this host:", err)
+		return
+	}
+	fmt.Println("sACN works, multicast:", multicast)
+}
+
+func TestCheckLoopback(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	multicast, err := CheckLoopback(ctx, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("frames were received via multicast: %v", multicast)
+
+	if _, err := CheckLoopback(ctx, 0); err == nil {
+		t.Error("Universe 0 should not be accepted!")
+	}
+}
@@ -0,0 +1,61 @@
+package sacn
+
+//MergeStrategy decides how the DMX data of multiple frames is merged into one frame
+type MergeStrategy int
+
+const (
+	//HTP (highest takes precedence) uses the highest value of all frames for every channel
+	HTP MergeStrategy = iota
+	//LTP (latest takes precedence) uses the value of the last frame that contains the channel. The
+	//frames have to be given in the order they were received.
+	LTP
+)
+
+/*
+Merge merges the given DMX frames channel by channel into one frame with the given strategy. This can be
+used to combine the data of multiple universes or sources into one output.
+
+The frames may have different lengths: the result is as long as the longest frame and a channel is only
+merged from the frames that contain it. Channels after the 512th are ignored. If no frames are given,
+the result is empty.
+*/
+func Merge(strategy MergeStrategy, frames ...[]byte) []byte {
+	length := 0
+	for _, frame := range frames {
+		if len(frame) > length {
+			length = len(frame)
+		}
+	}
+	if length > 512 {
+		length = 512
+	}
+	merged := make([]byte, length)
+	for _, frame := range frames {
+		if len(frame) > length {
+			frame = frame[:length]
+		}
+		for i, value := range frame {
+			if strategy == LTP || value > merged[i] {
+				merged[i] = value
+			}
+		}
+	}
+	return merged
+}
+
+//ChangedChannels returns the indices of all channels that differ between the old and the new DMX data.
+//The indices start with 0. If the data has different lengths, the channels that are only contained in
+//the longer data are changed, too. Use this to only update the channels that have changed.
+func ChangedChannels(old, new []byte) []int {
+	length := len(old)
+	if len(new) > length {
+		length = len(new)
+	}
+	changed := make([]int, 0)
+	for i := 0; i < length; i++ {
+		if i >= len(old) || i >= len(new) || old[i] != new[i] {
+			changed = append(changed, i)
+		}
+	}
+	return changed
+}
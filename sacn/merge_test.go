@@ -0,0 +1,48 @@
+package sacn
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	long := make([]byte, 600)
+	long[511], long[599] = 7, 9
+	tests := []struct {
+		strategy MergeStrategy
+		frames   [][]byte
+		want     []byte
+	}{
+		{HTP, nil, []byte{}},
+		{HTP, [][]byte{{1, 5, 0}, {3, 2}}, []byte{3, 5, 0}},
+		{LTP, [][]byte{{1, 5, 0}, {3, 2}}, []byte{3, 2, 0}},
+		{LTP, [][]byte{{3, 2}, {1, 5, 0}}, []byte{1, 5, 0}},
+		{HTP, [][]byte{{}, {4}}, []byte{4}},
+		{HTP, [][]byte{long}, long[:512]},
+	}
+	for i, tt := range tests {
+		if got := Merge(tt.strategy, tt.frames...); !bytes.Equal(got, tt.want) {
+			t.Errorf("%v: Wrong output! Was: %v; Should've been: %v", i, got, tt.want)
+		}
+	}
+}
+
+func TestChangedChannels(t *testing.T) {
+	tests := []struct {
+		old, new []byte
+		want     []int
+	}{
+		{nil, nil, []int{}},
+		{[]byte{1, 2, 3}, []byte{1, 2, 3}, []int{}},
+		{[]byte{1, 2, 3}, []byte{1, 5, 4}, []int{1, 2}},
+		{[]byte{1, 2}, []byte{1, 2, 0, 7}, []int{2, 3}},
+		{[]byte{1, 2, 3}, []byte{0}, []int{0, 1, 2}},
+	}
+	for i, tt := range tests {
+		got := ChangedChannels(tt.old, tt.new)
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%v: Wrong output! Was: %v; Should've been: %v", i, got, tt.want)
+		}
+	}
+}
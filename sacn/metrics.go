@@ -0,0 +1,36 @@
+package sacn
+
+//MetricsSink gets informed by the receiver about all events that are counted for Stats. Implement it
+//to export metrics to a monitoring system like Prometheus or statsd, see ReceiverSocket.SetMetricsSink.
+//The methods are called from the listener of the receiver, so they have to return quickly and must
+//not call methods of the receiver.
+type MetricsSink interface {
+	//IncPackets is called for every valid data packet that was received on the universe
+	IncPackets(universe uint16)
+	//IncParseErrors is called for every packet that was no valid E1.31 packet
+	IncParseErrors()
+	//IncDelivered is called for every change on the universe that is delivered to the callbacks,
+	//including the heartbeats of SetHeartbeatInterval
+	IncDelivered(universe uint16)
+	//IncSuppressed is called for every data packet that did not lead to a change on the universe
+	IncSuppressed(universe uint16)
+	//IncTimeouts is called, if the universe has timed out
+	IncTimeouts(universe uint16)
+	//IncConflicts is called for every data packet of another source with the same priority
+	IncConflicts(universe uint16)
+}
+
+//noopMetrics is the default MetricsSink that does nothing
+type noopMetrics struct{}
+
+func (noopMetrics) IncPackets(universe uint16)    {}
+func (noopMetrics) IncParseErrors()               {}
+func (noopMetrics) IncDelivered(universe uint16)  {}
+func (noopMetrics) IncSuppressed(universe uint16) {}
+func (noopMetrics) IncTimeouts(universe uint16)   {}
+func (noopMetrics) IncConflicts(universe uint16)  {}
+
+//metricsHolder wraps the MetricsSink, so that an atomic.Value always stores the same type
+type metricsHolder struct {
+	sink MetricsSink
+}
@@ -0,0 +1,60 @@
+package sacn
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+//countingSink counts all events in a map
+type countingSink struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (s *countingSink) inc(event string, universe uint16) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.counts[fmt.Sprintf("%v %v", event, universe)]++
+}
+
+func (s *countingSink) IncPackets(universe uint16)    { s.inc("packets", universe) }
+func (s *countingSink) IncParseErrors()               { s.inc("parseErrors", 0) }
+func (s *countingSink) IncDelivered(universe uint16)  { s.inc("delivered", universe) }
+func (s *countingSink) IncSuppressed(universe uint16) { s.inc("suppressed", universe) }
+func (s *countingSink) IncTimeouts(universe uint16)   { s.inc("timeouts", universe) }
+func (s *countingSink) IncConflicts(universe uint16)  { s.inc("conflicts", universe) }
+
+func TestMetricsSink(t *testing.T) {
+	p := NewDataPacket()
+	p.SetUniverse(3)
+	p.SetCID([16]byte{1})
+	p.SetData([]byte{1})
+	conflict := p.copy()
+	conflict.SetCID([16]byte{2})
+	conn := &fakeConn{reads: []fakeRead{{data: []byte("no sACN packet")}, {data: p.Bytes()}, {data: conflict.Bytes()}}}
+
+	r := newTestReceiver()
+	r.socket = conn
+	r.SetTimeout(50 * time.Millisecond)
+	sink := &countingSink{counts: make(map[string]int)}
+	r.SetMetricsSink(sink)
+	r.Start()
+	defer r.Close()
+
+	want := map[string]int{"parseErrors 0": 1, "packets 3": 2, "delivered 3": 1, "suppressed 3": 1,
+		"conflicts 3": 1, "timeouts 3": 1}
+	for start := time.Now(); time.Since(start) < time.Second; time.Sleep(time.Millisecond) {
+		sink.mu.Lock()
+		equal := reflect.DeepEqual(sink.counts, want)
+		sink.mu.Unlock()
+		if equal {
+			return
+		}
+	}
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	t.Errorf("Wrong metrics!\nWas:            %v\nShould've been: %v", sink.counts, want)
+}
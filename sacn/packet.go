@@ -0,0 +1,61 @@
+package sacn
+
+//PacketType is the type of an E1.31 packet, which is selected by the vectors of the root and the
+//framing layer. See ParsePacket.
+type PacketType int
+
+const (
+	//PacketUnknown is returned for bytes that are no valid E1.31 packet
+	PacketUnknown PacketType = iota
+	//PacketData is a data packet with DMX data, see DataPacket
+	PacketData
+	//PacketSync is a synchronization packet, see SyncPacket
+	PacketSync
+	//PacketDiscovery is a universe discovery packet, see DiscoveryPacket
+	PacketDiscovery
+)
+
+func (t PacketType) String() string {
+	switch t {
+	case PacketData:
+		return "data"
+	case PacketSync:
+		return "sync"
+	case PacketDiscovery:
+		return "discovery"
+	}
+	return "unknown"
+}
+
+//Packet is a parsed E1.31 packet. Only the field that belongs to the Type is set.
+type Packet struct {
+	Type      PacketType
+	Data      DataPacket
+	Sync      SyncPacket
+	Discovery DiscoveryPacket
+}
+
+//ParsePacket parses the raw bytes as the packet type that is given by the vectors of the packet.
+//Bytes that are neither a sync nor a discovery packet are parsed as data packet. If the bytes are not
+//a valid packet, the Type is PacketUnknown and an error is returned. The bytes are copied.
+func ParsePacket(raw []byte) (Packet, error) {
+	switch {
+	case isDiscoveryPacket(raw):
+		p, err := NewDiscoveryPacketRaw(raw)
+		if err != nil {
+			return Packet{}, err
+		}
+		return Packet{Type: PacketDiscovery, Discovery: p}, nil
+	case isSyncPacket(raw):
+		p, err := NewSyncPacketRaw(raw)
+		if err != nil {
+			return Packet{}, err
+		}
+		return Packet{Type: PacketSync, Sync: p}, nil
+	}
+	p, err := NewDataPacketRaw(raw)
+	if err != nil {
+		return Packet{}, err
+	}
+	return Packet{Type: PacketData, Data: p}, nil
+}
@@ -0,0 +1,44 @@
+package sacn
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestParsePacket(t *testing.T) {
+	data := NewDataPacket()
+	data.SetUniverse(5)
+	sync := NewSyncPacket()
+	sync.SetSyncAddress(7)
+	discovery := NewDiscoveryPacket()
+	discovery.SetUniverses([]uint16{1, 2})
+
+	p, err := ParsePacket(data.Bytes())
+	if err != nil || p.Type != PacketData || p.Data.Universe() != 5 {
+		t.Errorf("Data packet was parsed as %v, err %v", p.Type, err)
+	}
+	p, err = ParsePacket(sync.Bytes())
+	if err != nil || p.Type != PacketSync || p.Sync.SyncAddress() != 7 {
+		t.Errorf("Sync packet was parsed as %v, err %v", p.Type, err)
+	}
+	p, err = ParsePacket(discovery.Bytes())
+	if err != nil || p.Type != PacketDiscovery || !bytes.Equal(p.Discovery.Bytes(), discovery.Bytes()) {
+		t.Errorf("Discovery packet was parsed as %v, err %v", p.Type, err)
+	}
+
+	//a packet with the vectors of a sync packet, but an invalid length
+	p, err = ParsePacket(sync.Bytes()[:48])
+	if err == nil || p.Type != PacketUnknown {
+		t.Errorf("Invalid sync packet should return an error, got %v", p.Type)
+	}
+	//unknown vectors
+	raw := data.Bytes()
+	raw[21] = 9
+	p, err = ParsePacket(raw)
+	if err == nil || p.Type != PacketUnknown {
+		t.Errorf("Unknown root vector should return an error, got %v", p.Type)
+	}
+	if _, err := ParsePacket(nil); err == nil {
+		t.Error("Empty bytes should return an error!")
+	}
+}
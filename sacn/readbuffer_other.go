@@ -0,0 +1,15 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+// +build !linux,!darwin,!dragonfly,!freebsd,!netbsd,!openbsd
+
+package sacn
+
+import (
+	"errors"
+	"net"
+)
+
+//readBufferSize returns the size of the receive buffer of the socket. This is not supported on this
+//operating system.
+func readBufferSize(conn *net.UDPConn) (int, error) {
+	return 0, errors.New("the size of the read buffer can not be obtained on this operating system")
+}
@@ -1,97 +1,1153 @@
 package sacn
 
 import (
+	"bytes"
+	"context"
+	"fmt"
 	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
-
-	"golang.org/x/net/ipv4"
 )
 
-//Set the timout according to the E1.31 protocol
+//Set the default timout according to the E1.31 protocol
 const timeoutMs = 2500
 
+//defaultPort is the UDP port that is used for sACN
+const defaultPort = 5568
+
+//maxPacketSize is the size of the biggest E1.31 packet, a universe discovery packet with 512 universes.
+//Data packets have at most 638 bytes.
+const maxPacketSize = 1144
+
+//errPacketTooLarge is logged for packets that do not fit into the buffer of the listener
+var errPacketTooLarge = fmt.Errorf("the packet is larger than %v bytes", maxPacketSize)
+
+//maxReadFailures is the count of consecutive failed reads, after which ErrInterfaceDown is reported
+const maxReadFailures = 3
+
+//timeoutChecks is the count of checks for timeouts per timeout interval, see watchTimeouts
+const timeoutChecks = 4
+
 //ReceiverSocket is used to listen on a network interface for sACN data.
-//The OnChangeCallback is used for changed DMX data. So if a source or priority changed,
-//this callback will not be invoked if not the DMX data has changed.
+//The OnChangeCallback is used for changed DMX data or priority. So if only the source changed,
+//this callback will not be invoked.
 //This Receiver checks for out-of-order packets and sorts out packets with too low priority.
+//Packets with a priority above 200 are out of the specification and handled with the priority 200.
+//Data packets that are received more than once, eg via unicast and multicast, are only handled once.
 type ReceiverSocket struct {
-	socket             *ipv4.PacketConn
+	//timeout is the network data loss timeout in nanoseconds. Only access it atomically!
+	//It is the first field to guarantee the 64-bit alignment that is needed for atomic access.
+	timeout            int64
+	priorityTimeout    int64         //the priority timeout in nanoseconds, 0 if it is the timeout. Only access it atomically!
+	heartbeat          int64         //the heartbeat interval in nanoseconds, 0 if it is off. Only access it atomically!
+	dropped            uint64        //the count of dropped changes, see SetDeliveryQueue. Only access it atomically!
+	stats              receiverStats //the counters for Stats. Only access them atomically!
+	metricsSink        atomic.Value  //the MetricsSink in a metricsHolder
+	recorder           atomic.Value  //the Recorder in a recorderHolder
+	logger             atomic.Value  //the Logger in a loggerHolder
+	socket             packetConn
+	conn               net.PacketConn //the connection that is used by the socket
 	stopListener       chan struct{}
-	multicastInterface *net.Interface // the interface that is used for joining multicast groups
+	listenerDone       chan struct{}  //gets closed, if the listener goroutine has finished
+	multicastInterface *net.Interface // the interface that is used for joining multicast groups. Guarded by mu
+	mu                 sync.Mutex     //guards the closed flag, the listener channels and the options
+	closed             bool
+	dropPreview        bool //if true, packets with the preview data bit set are dropped
+	strictDestination  bool //if true, multicast packets of groups that are not joined are dropped
+	closeOnce          sync.Once
+	closeErr           error
+	//ctx stops the listener and closes the socket, if it is done
+	ctx context.Context
+	//now returns the current time for all timeouts. It is time.Now and only replaced in tests.
+	now func() time.Time
+	//heartbeatChanged signals a changed heartbeat interval to the heartbeat goroutine
+	heartbeatChanged chan struct{}
+	//stateMu guards the callbacks and all stores below that are used by the handlers
+	stateMu    sync.Mutex
+	queue      chan change   //the queue for the OnChangeCallback, nil if every change gets its own goroutine
+	queueDone  chan struct{} //gets closed, if all changes of the last queue were delivered
+	dropPolicy DropPolicy
+	//arbitration decides which source wins, if multiple sources send with the same priority
+	arbitration      ArbitrationPolicy
+	ignoreSequence   bool              //true, if the sequence numbers of the packets are not checked
+	deliverUnchanged bool              //true, if frames with unchanged data are delivered, too
+	interest         map[uint16][2]int //the first and last channel for the change detection, see SetInterest
+	allowed          map[[16]byte]bool //the sources whose packets are accepted, all if it is empty
+	denied           map[[16]byte]bool //the sources whose packets are dropped
+	dedup            *dedup            //detects data packets that were received more than once
+	idleTTL          time.Duration     //the time after which the data of idle universes is removed, 0 to keep it
+	//universeMap maps the universe of received packets to the universe that is used, nil if unused
+	universeMap func(in uint16) (out uint16, keep bool)
 	//OnChangeCallback gets called if the data on one universe has changed. Gets called in own goroutine
 	onChangeCallback func(old DataPacket, new DataPacket)
 	//TimeoutCallback gets called, if a timout on a universe occurs. Gets called in own goroutine
 	timeoutCallback func(universe uint16)
-	lastDatas       map[uint16]lastData
-	timeoutCalled   map[uint16]bool //true, if the timeout was called. To prevent send a timeoutcallback twice
+	//the handlers of OnData for single universes. Get called like the OnChangeCallback
+	dataHandlers map[uint16]func(p DataPacket)
+	//the channels of WaitForData calls that wait for the next frame of a universe
+	waiters map[uint16][]chan DataPacket
+	views   map[uint16][]*Universe //the Universes that are updated with every frame
+	//ErrorCallback gets called for timeouts and terminated streams. Gets called in own goroutine
+	errorCallback func(err ReceiveError)
+	//RecoveredCallback gets called, if data is received again on a timed out universe
+	recoveredCallback func(universe uint16)
+	//NewUniverseCallback gets called, if data is received on a universe that had no data before
+	newUniverseCallback func(universe uint16)
+	//TerminationCallback gets called, if the source of a universe has terminated its stream
+	terminationCallback func(universe uint16)
+	//SourceChangeCallback gets called, if another source is used for a universe
+	sourceChangeCallback func(universe uint16, oldCID, newCID [16]byte, priority byte)
+	//the source changes that wait for the SourceChangeCallback, the first one is being delivered
+	sourceChanges []sourceChange
+	//DiscoveryCallback gets called, if a source has announced all of its universes
+	discoveryCallback func(cid [16]byte, sourceName string, universes []uint16)
+	//StartCodeCallback gets called for packets with an alternate start code. Gets called in own goroutine
+	startCodeCallback func(p DataPacket)
+	discoveryPages    map[[16]byte]discoveryPages //the received pages of every source
+	syncHeld          map[uint16]*heldData        //the data that waits for a sync packet, per sync address
+	lastSync          map[uint16]time.Time        //the time of the last sync packet, per sync address
+	syncSequences     map[syncSource]syncState    //the last sync packet of every source, per sync address
+	//all sources of universes that use per-address priorities
+	perAddress    map[uint16]map[[16]byte]*perAddressSource
+	sources       map[uint16]map[[16]byte]SourceInfo //all sources that send on a universe
+	lastDatas     map[uint16]lastData
+	timeoutCalled map[uint16]bool //true, if the universe has timed out. To prevent send a timeoutcallback twice
+	joined        map[uint16]bool //all universes whose multicast-groups are joined. Guarded by mu
 }
 
 type lastData struct {
 	lastTime   time.Time
 	lastPacket DataPacket
+	dropped    bool //the source of the packet was dropped via DropSource
+}
+
+//SourceInfo contains the information about a source that sends on a universe
+type SourceInfo struct {
+	CID        [16]byte
+	SourceName string
+	Priority   byte         //the priority of the last data packet of the source
+	LastSeen   time.Time    //the time the last packet of the source was received
+	Timing     SourceTiming //the intervals between the DMX frames of the source
+	//conflictReported is true, if ErrSourcesExceeded was reported for the source
+	conflictReported bool
+}
+
+/*
+SourceTiming contains the statistics of the intervals between the arrivals of the DMX frames (start
+code 0x00) of a source on a universe. It helps to find sources that do not meet the timing of E1.31,
+which is at most 44 frames per second and at least one frame per second as keep alive. The Jitter is
+the smoothed mean deviation between consecutive intervals as in RFC 3550.
+The statistics are kept as long as the source is known; they are reset if the source times out.
+*/
+type SourceTiming struct {
+	Intervals uint64        //the count of measured intervals
+	Min       time.Duration //the shortest interval
+	Max       time.Duration //the longest interval
+	Mean      time.Duration //the average interval
+	Jitter    time.Duration //the smoothed deviation between consecutive intervals
+	total     time.Duration //the sum of all intervals
+	last      time.Duration //the last interval
+	lastFrame time.Time     //the time of the last frame
+}
+
+//add adds the arrival of a frame at the given time
+func (t *SourceTiming) add(now time.Time) {
+	if t.lastFrame.IsZero() {
+		t.lastFrame = now
+		return
+	}
+	interval := now.Sub(t.lastFrame)
+	t.lastFrame = now
+	if t.Intervals == 0 || interval < t.Min {
+		t.Min = interval
+	}
+	if interval > t.Max {
+		t.Max = interval
+	}
+	if t.Intervals > 0 {
+		deviation := interval - t.last
+		if deviation < 0 {
+			deviation = -deviation
+		}
+		t.Jitter += (deviation - t.Jitter) / 16
+	}
+	t.last = interval
+	t.Intervals++
+	t.total += interval
+	t.Mean = t.total / time.Duration(t.Intervals)
+}
+
+//change is a change of data that is waiting in the queue for the OnChangeCallback
+type change struct {
+	old DataPacket
+	new DataPacket
+}
+
+//sourceChange is a change of the source of a universe that is waiting for the SourceChangeCallback
+type sourceChange struct {
+	callback       func(universe uint16, oldCID, newCID [16]byte, priority byte)
+	universe       uint16
+	oldCID, newCID [16]byte
+	priority       byte
+}
+
+//Stats is a snapshot of the counters of a receiver, see ReceiverSocket.Stats
+type Stats struct {
+	PacketsReceived uint64 //all packets that were read from the socket
+	ParseErrors     uint64 //packets that were no valid E1.31 packets
+	Delivered       uint64 //changes that were delivered to the callbacks, including heartbeats
+	Suppressed      uint64 //data packets that did not lead to a change, eg because nothing has changed
+	Timeouts        uint64 //how often a universe has timed out
+	Conflicts       uint64 //data packets of another source with the same priority, see SetArbitrationPolicy
+	//Sequences contains the sequence number of the last used packet of every universe
+	Sequences map[uint16]byte
+}
+
+//receiverStats are the counters of a receiver. They are only accessed atomically.
+type receiverStats struct {
+	packetsReceived uint64
+	parseErrors     uint64
+	delivered       uint64
+	suppressed      uint64
+	timeouts        uint64
+	conflicts       uint64
+}
+
+//DropPolicy decides which change is dropped, if the delivery queue of a receiver is full
+type DropPolicy int
+
+const (
+	//DropNewest drops the change that does not fit into the full queue anymore
+	DropNewest DropPolicy = iota
+	//DropOldest drops the oldest change in the queue to make room for the new one
+	DropOldest
+)
+
+/*
+ArbitrationPolicy decides which source is used for a universe, if multiple sources send on the universe
+with the same priority. E1.31 does not allow multiple sources with the same priority, but some setups
+use them intentionally (eg backup sources that are synchronized).
+
+The policies are:
+  - ErrorOnConflict (default) treats another source with the same priority as an error, as E1.31
+    intends: it is reported once per source as ErrSourcesExceeded to the error callback and its packets
+    are dropped. The source that was used first keeps the universe like with FirstReceived. Note that
+    the data of a second source is never used, not even if it is an intended backup with the same
+    priority, until the first source times out or terminates its stream.
+  - LastReceived uses the packet that arrived last. If the sources send different data, the output
+    flickers between them.
+  - FirstReceived keeps the source that was used first, until it times out or terminates its stream.
+    The other sources are ignored until then, so the output is stable, but a failing source is only
+    replaced after the timeout.
+  - HighestCID uses the source with the highest CID. Every receiver with this policy chooses the same
+    source, regardless of the order the packets arrive in.
+
+The receiver does not halt the data of a universe on a conflict, so every policy keeps delivering the
+data of one source.
+*/
+type ArbitrationPolicy int
+
+const (
+	//ErrorOnConflict reports other sources with the same priority as ErrSourcesExceeded
+	ErrorOnConflict ArbitrationPolicy = iota
+	//LastReceived uses the last received packet of all sources with the same priority
+	LastReceived
+	//FirstReceived keeps the source that was used first for the universe
+	FirstReceived
+	//HighestCID uses the source with the highest CID
+	HighestCID
+)
+
+//syncSource identifies the sync packets of one source on one sync address
+type syncSource struct {
+	cid  [16]byte
+	sync uint16
+}
+
+//syncState is the sequence number and time of the last sync packet of a source
+type syncState struct {
+	sequence byte
+	time     time.Time
+}
+
+//heldData holds the data packets that wait for a sync packet
+type heldData struct {
+	since   time.Time             //the time since when the data is held
+	packets map[uint16]DataPacket //the latest packet for every universe
+}
+
+//discoveryPages holds the received pages of the universe discovery of one source
+type discoveryPages struct {
+	lastPage byte
+	pages    map[byte]DiscoveryPacket
 }
 
 /*
 NewReceiverSocket creates a new unicast Receiversocket that is capable of listening on the given
 interface (string is for binding). bind can be something like "192.168.1.2" (without a port!).
-This bind is only used for unicast receiving.
-The net.Interface is used to join multicast groups. On some OS (eg Windows) you have
-to provide an interface for multicast to work. On others "nil" may be enough. If you dont want
-to use multicast for receiving, just provide "nil".
+This bind is only used for unicast receiving. On hosts with multiple network interfaces, binding to
+the address of one interface prevents receiving the same unicast traffic on every interface.
+Use "" or "0.0.0.0" to listen on all interfaces. An error is returned, if the bind address can not be
+resolved or does not belong to the given interface.
+The net.Interface is used to join multicast groups. If you dont want to use multicast for
+receiving, just provide "nil". Joining a universe is not possible without an interface.
+Note that on some operating systems (eg Linux) a socket that is bound to a unicast address does not
+receive multicast traffic, so use "" as bind if you want to use multicast there.
 */
 func NewReceiverSocket(bind string, ifi *net.Interface) (*ReceiverSocket, error) {
-	r := &ReceiverSocket{}
+	return NewReceiverSocketContext(context.Background(), bind, ifi)
+}
+
+/*
+NewReceiverSocketContext creates a new Receiversocket like NewReceiverSocket does, but the lifetime
+of the receiver is bound to the given context. If the context gets cancelled, the running listener
+stops and the udp socket gets closed, just like a call to Close() would do.
+*/
+func NewReceiverSocketContext(ctx context.Context, bind string, ifi *net.Interface) (*ReceiverSocket, error) {
+	return newReceiverSocketListen(ctx, "udp4", bind, defaultPort, ifi)
+}
+
+/*
+NewReceiverSocketIPv6 creates a new Receiversocket like NewReceiverSocket does, but uses IPv6.
+bind can be something like "fe80::1" or "" (without a port!). The multicast-groups of the universes
+are the sACN IPv6 groups ff18::83:0:0:<universe>.
+*/
+func NewReceiverSocketIPv6(bind string, ifi *net.Interface) (*ReceiverSocket, error) {
+	return newReceiverSocketListen(context.Background(), "udp6", bind, defaultPort, ifi)
+}
+
+/*
+NewReceiverSocketPort creates a new Receiversocket like NewReceiverSocket does, but listens on the
+given UDP port instead of the sACN port 5568. This is useful for tests and non-standard setups.
+Note that the multicast-groups are the same for every port, but the sources have to send to this port.
+*/
+func NewReceiverSocketPort(bind string, port int, ifi *net.Interface) (*ReceiverSocket, error) {
+	return newReceiverSocketListen(context.Background(), "udp4", bind, port, ifi)
+}
+
+/*
+NewReceiverSocketConn creates a new Receiversocket that uses the given connection instead of opening a
+new udp socket. This makes it possible to use sockets that were created with special options, or to
+inject packets in tests. The receiver closes the connection on Close.
+Multicast is only supported for UDP sockets (*net.UDPConn). For all other connections joining and
+leaving universes does nothing, but JoinUniverse still returns ErrNoMulticastInterface, if ifi is nil.
+*/
+func NewReceiverSocketConn(conn net.PacketConn, ifi *net.Interface) *ReceiverSocket {
+	r := newReceiverSocket(context.Background(), ifi)
+	r.conn = conn
+	r.socket = newPacketConn(conn)
+	return r
+}
 
-	ServerConn, err := net.ListenPacket("udp4", bind+":5568")
+//newReceiverSocketListen creates a new receiver that listens on the given address and port.
+//network is "udp4" or "udp6".
+func newReceiverSocketListen(ctx context.Context, network, bind string, port int, ifi *net.Interface) (*ReceiverSocket, error) {
+	r := newReceiverSocket(ctx, ifi)
+
+	if err := checkBind(network, bind, ifi); err != nil {
+		return r, err
+	}
+	ServerConn, err := net.ListenPacket(network, net.JoinHostPort(bind, strconv.Itoa(port)))
 	if err != nil {
 		return r, err
 	}
-	r.multicastInterface = ifi
-	r.socket = ipv4.NewPacketConn(ServerConn)
-	r.lastDatas = make(map[uint16]lastData)
-	r.timeoutCalled = make(map[uint16]bool)
+	r.conn = ServerConn
+	if network == "udp6" {
+		r.socket = newIPv6Conn(ServerConn)
+	} else {
+		r.socket = newIPv4Conn(ServerConn)
+	}
 	return r, nil
 }
 
+//checkBind checks that the bind address can be resolved to an address of the network ("udp4" or "udp6")
+//and, if an interface is given, that the address belongs to this interface
+func checkBind(network, bind string, ifi *net.Interface) error {
+	if bind == "" {
+		return nil
+	}
+	ipNetwork := "ip4"
+	if network == "udp6" {
+		ipNetwork = "ip6"
+	}
+	addr, err := net.ResolveIPAddr(ipNetwork, bind)
+	if err != nil {
+		return fmt.Errorf("the bind address %q could not be resolved: %v", bind, err)
+	}
+	if ifi == nil || addr.IP.IsUnspecified() {
+		return nil
+	}
+	addrs, err := ifi.Addrs()
+	if err != nil {
+		return err
+	}
+	for _, a := range addrs {
+		if ipNet, ok := a.(*net.IPNet); ok && ipNet.IP.Equal(addr.IP) {
+			return nil
+		}
+	}
+	return fmt.Errorf("the bind address %v does not belong to the interface %v", addr.IP, ifi.Name)
+}
+
+//newReceiverSocket creates a receiver with all its internal stores, but without a socket
+func newReceiverSocket(ctx context.Context, ifi *net.Interface) *ReceiverSocket {
+	r := &ReceiverSocket{
+		ctx:                ctx,
+		now:                time.Now,
+		heartbeatChanged:   make(chan struct{}, 1),
+		multicastInterface: ifi,
+		sources:            make(map[uint16]map[[16]byte]SourceInfo),
+		dataHandlers:       make(map[uint16]func(p DataPacket)),
+		waiters:            make(map[uint16][]chan DataPacket),
+		views:              make(map[uint16][]*Universe),
+		allowed:            make(map[[16]byte]bool),
+		denied:             make(map[[16]byte]bool),
+		interest:           make(map[uint16][2]int),
+		dedup:              newDedup(),
+		lastDatas:          make(map[uint16]lastData),
+		timeoutCalled:      make(map[uint16]bool),
+		joined:             make(map[uint16]bool),
+		discoveryPages:     make(map[[16]byte]discoveryPages),
+		syncHeld:           make(map[uint16]*heldData),
+		lastSync:           make(map[uint16]time.Time),
+		syncSequences:      make(map[syncSource]syncState),
+		perAddress:         make(map[uint16]map[[16]byte]*perAddressSource),
+	}
+	r.SetTimeout(time.Millisecond * timeoutMs)
+	r.SetMetricsSink(nil)
+	r.SetRecorder(nil)
+	r.SetLogger(nil)
+	return r
+}
+
 //JoinUniverse joins the used udp socket to the multicast-group that is used for the universe.
 //After the multicast-group was joined, any source that transmitt on this universe via multicast
 //should reach this socket.
+//An error is returned if the receiver has no multicast interface (ErrNoMulticastInterface), if the
+//universe is not in the range [1-63999] or if the group could not be joined. Joining an already joined
+//universe does nothing.
 //Please read the notice above about multicast use.
-func (r *ReceiverSocket) JoinUniverse(universe uint16) {
-	r.socket.JoinGroup(r.multicastInterface, calcMulticastUDPAddr(universe))
+func (r *ReceiverSocket) JoinUniverse(universe uint16) error {
+	if err := checkUniverse(universe); err != nil {
+		return err
+	}
+	return r.joinGroup(universe)
+}
+
+//joinGroup joins the multicast-group of the given universe without checking the universe range
+func (r *ReceiverSocket) joinGroup(universe uint16) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.multicastInterface == nil {
+		return ErrNoMulticastInterface
+	}
+	if r.joined[universe] {
+		return nil
+	}
+	if err := r.socket.JoinGroup(r.multicastInterface, universe); err != nil {
+		if logger := r.log(); logger != nil {
+			logger.Warn("could not join multicast-group", "universe", universe, "err", err)
+		}
+		return err
+	}
+	r.joined[universe] = true
+	return nil
 }
 
 //LeaveUniverse will leave the mutlicast-group of the given universe.
 //If the the socket was not joined to the multicast-group nothing will happen.
 //Please note, that if you leave a group, a timeout may occurr, because no more data has arrived.
-func (r *ReceiverSocket) LeaveUniverse(universe uint16) {
-	r.socket.LeaveGroup(r.multicastInterface, calcMulticastUDPAddr(universe))
+func (r *ReceiverSocket) LeaveUniverse(universe uint16) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.joined[universe] {
+		return nil
+	}
+	if err := r.socket.LeaveGroup(r.multicastInterface, universe); err != nil {
+		return err
+	}
+	delete(r.joined, universe)
+	return nil
 }
 
-//Close will close the open udp socket and stops the running goroutine.
-//If you want to receive again, use Start(). Do not call close twice!
-func (r *ReceiverSocket) Close() {
-	close(r.stopListener) // stop the running listener on the socket, because we will close the socket
+//JoinUniverseRange joins the multicast-groups of all universes from first to last (including both),
+//eg for a node that uses the universes 1-64. If a universe could not be joined, the other universes
+//are joined anyway and a *RangeError with all failed universes is returned.
+//An error is returned without joining any universe, if the range is invalid.
+func (r *ReceiverSocket) JoinUniverseRange(first, last uint16) error {
+	if err := checkUniverseRange(first, last); err != nil {
+		return err
+	}
+	return forUniverseRange(first, last, r.JoinUniverse)
+}
+
+//LeaveUniverseRange leaves the multicast-groups of all universes from first to last (including both).
+//Like JoinUniverseRange, a *RangeError is returned if some universes could not be left.
+func (r *ReceiverSocket) LeaveUniverseRange(first, last uint16) error {
+	if err := checkUniverseRange(first, last); err != nil {
+		return err
+	}
+	return forUniverseRange(first, last, r.LeaveUniverse)
+}
+
+/*
+SetActiveUniverses sets the universes that are received via multicast, eg for a gateway that gets
+its universes from a controller at runtime. Only the difference to the currently joined universes is
+applied: the multicast-groups of new universes are joined and the groups of universes that are not in
+the set anymore are left. The last data of the left universes is removed, unless it is still used by
+OnData, a Universe or WaitForData. The universe discovery universe is not affected.
+The change is applied at once, so no packet is handled with a partially applied set. If some groups
+could not be joined or left, the other universes are changed anyway and a *RangeError with the failed
+universes is returned. An error is returned without changing anything, if a universe is invalid.
+*/
+func (r *ReceiverSocket) SetActiveUniverses(set map[uint16]struct{}) error {
+	for universe := range set {
+		if err := checkUniverse(universe); err != nil {
+			return err
+		}
+	}
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+	failed, left := r.applyActiveUniverses(set)
+	for _, universe := range left {
+		if !r.isUsed(universe) {
+			r.removeUniverse(universe)
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return &RangeError{Failed: failed}
+}
+
+/*
+SetMulticastInterface changes the interface that is used for multicast. All joined multicast-groups
+are left on the old interface and joined again on the new one, so the state of the receiver is kept.
+Use nil to disable multicast: all groups are left and JoinUniverse returns ErrNoMulticastInterface
+afterwards. If some groups could not be joined on the new interface, they are not joined anymore and
+a *RangeError with these universes is returned.
+*/
+func (r *ReceiverSocket) SetMulticastInterface(ifi *net.Interface) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	failed := make(map[uint16]error)
+	for universe := range r.joined {
+		if r.multicastInterface != nil {
+			r.socket.LeaveGroup(r.multicastInterface, universe)
+		}
+		if ifi == nil {
+			delete(r.joined, universe)
+		} else if err := r.socket.JoinGroup(ifi, universe); err != nil {
+			if logger := r.log(); logger != nil {
+				logger.Warn("could not join multicast-group", "universe", universe, "err", err)
+			}
+			failed[universe] = err
+			delete(r.joined, universe)
+		}
+	}
+	r.multicastInterface = ifi
+	if len(failed) == 0 {
+		return nil
+	}
+	return &RangeError{Failed: failed}
+}
+
+//JoinedUniverses returns all universes whose multicast-groups are currently joined. The returned
+//slice is a copy and is sorted ascending.
+func (r *ReceiverSocket) JoinedUniverses() []uint16 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	list := make([]uint16, 0, len(r.joined))
+	for univ := range r.joined {
+		if univ != discoveryUniverse {
+			list = append(list, univ)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i] < list[j] })
+	return list
+}
+
+//JoinDiscovery joins the multicast-group that is used for universe discovery packets.
+//See SetDiscoveryCallback for receiving them. Use LeaveDiscovery to leave the group again.
+func (r *ReceiverSocket) JoinDiscovery() error {
+	return r.joinGroup(discoveryUniverse)
+}
+
+//LeaveDiscovery leaves the multicast-group that is used for universe discovery packets.
+func (r *ReceiverSocket) LeaveDiscovery() error {
+	return r.LeaveUniverse(discoveryUniverse)
+}
+
+/*
+SetReadBuffer sets the size of the receive buffer of the operating system for the socket in bytes and
+returns the size that is effectively used. The operating system may use another size than requested:
+Linux doubles the value and limits it to net.core.rmem_max. If the effective size can not be obtained
+on this operating system, the requested size is returned.
+
+With many universes the default buffer may overflow before the packets are read, so that packets get
+lost. Every universe is send with up to 44 packets per second with up to 638 bytes each, so the buffer
+should be able to hold at least some frames of all universes, eg 100 universes * 638 bytes * 4 frames
+= ~256KB. Returns an error, if the connection of the receiver does not support this.
+*/
+func (r *ReceiverSocket) SetReadBuffer(bytes int) (int, error) {
+	conn, ok := r.conn.(*net.UDPConn)
+	if !ok {
+		return 0, fmt.Errorf("the connection of the receiver does not support setting the read buffer")
+	}
+	if err := conn.SetReadBuffer(bytes); err != nil {
+		return 0, err
+	}
+	size, err := readBufferSize(conn)
+	if err != nil {
+		return bytes, nil
+	}
+	return size, nil
+}
+
+//Close will close the open udp socket and stops the running goroutine. Close waits until the
+//goroutine has stopped, so no new callbacks get invoked after Close has returned. Callbacks that were
+//invoked before may still be running, because they run in their own goroutines. The changes that are
+//still in the delivery queue (see SetDeliveryQueue) are delivered after Close has returned, use
+//CloseAndDrain to wait for them.
+//It is safe to call Close more than once, only the first call has an effect.
+//A closed receiver can not be started again, create a new one instead.
+func (r *ReceiverSocket) Close() error {
+	r.mu.Lock()
+	if !r.closed {
+		r.closed = true
+		if r.stopListener != nil {
+			close(r.stopListener) // stop the running listener on the socket, because we will close the socket
+		}
+	}
+	done := r.listenerDone
+	r.mu.Unlock()
+
+	err := r.closeSocket() //closing the socket lets a blocking read return immediately
+	if done != nil {
+		<-done
+	}
+	r.stateMu.Lock()
+	r.stopDelivery()
+	r.stateMu.Unlock()
+	return err
+}
+
+/*
+CloseAndDrain closes the receiver like Close does and then waits until all changes in the delivery
+queue were delivered to the callbacks, so that no frame that was received before the close is lost.
+The listener is stopped first, so no new changes are queued while the queue is drained:
+
+	recv.SetDeliveryQueue(100, sacn.DropOldest)
+	recv.OnData(1, record)
+	recv.Start()
+	...
+	recv.CloseAndDrain() //record has got every queued frame and is not called anymore
+
+Without a delivery queue every callback runs in its own goroutine and CloseAndDrain does not wait for
+them. CloseAndDrain must not be called from a callback, because it would wait for itself.
+*/
+func (r *ReceiverSocket) CloseAndDrain() error {
+	err := r.Close()
+	r.stateMu.Lock()
+	done := r.queueDone
+	r.stateMu.Unlock()
+	if done != nil {
+		<-done
+	}
+	return err
+}
+
+//closeSocket closes the udp socket exactly once and returns the error of that close
+func (r *ReceiverSocket) closeSocket() error {
+	r.closeOnce.Do(func() {
+		r.closeErr = r.socket.Close()
+	})
+	return r.closeErr
 }
 
 //Start starts a seperate goroutine for handling incoming sACN traffic.
-//If the goroutine is already running, nothing happens. If Close() was called previously,
-//kkep in mind, that it takes up to 2.5 seconds to stop the existing goroutine.
+//If the goroutine is already running or the receiver was closed, nothing happens.
 func (r *ReceiverSocket) Start() {
-	if r.stopListener == nil {
-		r.stopListener = make(chan struct{})
-		r.startListener()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed || r.stopListener != nil {
+		return
+	}
+	r.stopListener = make(chan struct{})
+	r.listenerDone = make(chan struct{})
+	r.startListener()
+}
+
+//LastData returns a copy of the last DMX data that was received on the universe. This can be used to
+//get the current state of a universe without waiting for the next change. Returns false, if no data
+//was received on the universe.
+func (r *ReceiverSocket) LastData(universe uint16) ([]byte, bool) {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+	last, ok := r.lastDatas[universe]
+	if !ok {
+		return nil, false
 	}
+	return append([]byte(nil), last.lastPacket.Data()...), true
+}
+
+//IsReceiving returns true, if data was received on the universe within the timeout, so the universe
+//has a source that has not timed out or terminated its stream.
+func (r *ReceiverSocket) IsReceiving(universe uint16) bool {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+	last, ok := r.lastDatas[universe]
+	return ok && r.now().Sub(last.lastTime) <= r.Timeout()
+}
+
+//WaitForData blocks until the next frame of the used source of the universe was received or the
+//context is done. In contrast to OnData, the frame is returned even if its data has not changed.
+//If the context is done first, a ReceiveError with the error of the context is returned.
+func (r *ReceiverSocket) WaitForData(ctx context.Context, universe uint16) (DataPacket, error) {
+	ch := make(chan DataPacket, 1)
+	r.stateMu.Lock()
+	r.waiters[universe] = append(r.waiters[universe], ch)
+	r.stateMu.Unlock()
+
+	select {
+	case p := <-ch:
+		return p, nil
+	case <-ctx.Done():
+	}
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+	waiters := r.waiters[universe]
+	for i, waiter := range waiters {
+		if waiter == ch {
+			r.waiters[universe] = append(waiters[:i:i], waiters[i+1:]...)
+			break
+		}
+	}
+	if len(r.waiters[universe]) == 0 {
+		delete(r.waiters, universe)
+	}
+	return DataPacket{}, ReceiveError{Universe: universe, Err: ctx.Err()}
+}
+
+//Sources returns all sources that are currently sending on the universe, sorted by priority from the
+//highest to the lowest. Sources that have timed out or terminated their stream are not included.
+//This includes the sources that are not used for the data of the universe, because another source
+//has a higher priority.
+func (r *ReceiverSocket) Sources(universe uint16) []SourceInfo {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+	list := make([]SourceInfo, 0, len(r.sources[universe]))
+	for _, info := range r.sources[universe] {
+		list = append(list, info)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Priority != list[j].Priority {
+			return list[i].Priority > list[j].Priority
+		}
+		return bytes.Compare(list[i].CID[:], list[j].CID[:]) < 0
+	})
+	return list
+}
+
+//SetTimeout sets the time after which a universe is considered as timed out, if no data was received.
+//The default is 2.5 seconds as defined in the E1.31 protocol. This timeout is also used for deciding
+//when a source with a lower priority may take over a universe, unless SetPriorityTimeout is used.
+//It is safe to call SetTimeout while the receiver is running. Returns an error and keeps the old
+//timeout, if the timeout is not greater than 0.
+func (r *ReceiverSocket) SetTimeout(timeout time.Duration) error {
+	if timeout <= 0 {
+		return fmt.Errorf("the timeout has to be greater than 0, was %v", timeout)
+	}
+	atomic.StoreInt64(&r.timeout, int64(timeout))
+	return nil
+}
+
+//Timeout returns the currently used timeout. See SetTimeout.
+func (r *ReceiverSocket) Timeout() time.Duration {
+	return time.Duration(atomic.LoadInt64(&r.timeout))
+}
+
+/*
+SetPriorityTimeout sets the time after which a source with a lower priority takes over a universe, if
+the source with the higher priority has stopped sending. This is the failover latency for backup
+sources: if the primary source sends with priority 200 and the backup with priority 100, the data of
+the backup is used from its first packet after the primary was silent for the priority timeout.
+A priority timeout of 0 (the default) uses the timeout of SetTimeout. A priority timeout that is
+longer than the timeout has no effect, because the primary source times out before.
+It is safe to call SetPriorityTimeout while the receiver is running.
+*/
+func (r *ReceiverSocket) SetPriorityTimeout(timeout time.Duration) {
+	atomic.StoreInt64(&r.priorityTimeout, int64(timeout))
+}
+
+//PriorityTimeout returns the currently used priority timeout. See SetPriorityTimeout.
+func (r *ReceiverSocket) PriorityTimeout() time.Duration {
+	if timeout := atomic.LoadInt64(&r.priorityTimeout); timeout > 0 {
+		return time.Duration(timeout)
+	}
+	return r.Timeout()
+}
+
+/*
+SetHeartbeatInterval lets the receiver deliver the last data of every universe that is receiving
+data with the given interval, even if the data has not changed. This can be used eg for watchdogs that
+have to know that the receiver is alive and the universes are healthy. The data is delivered like a
+change to the handlers of OnData and the OnChangeCallback, where the old and new data are the same.
+Universes that have timed out or were terminated get no heartbeat. The heartbeat is only sent while
+the receiver is started. Heartbeats are counted as delivered changes in Stats and the MetricsSink.
+Use 0 to turn it off, which is the default.
+*/
+func (r *ReceiverSocket) SetHeartbeatInterval(interval time.Duration) {
+	atomic.StoreInt64(&r.heartbeat, int64(interval))
+	select {
+	case r.heartbeatChanged <- struct{}{}:
+	default: //the goroutine is already notified
+	}
+}
+
+//HeartbeatInterval returns the currently used heartbeat interval. See SetHeartbeatInterval.
+func (r *ReceiverSocket) HeartbeatInterval() time.Duration {
+	return time.Duration(atomic.LoadInt64(&r.heartbeat))
+}
+
+//SetSourceChangeCallback sets the callback for source changes. It gets called, if the source that is
+//used for a universe changes, eg because a source with a higher priority has taken over or the
+//primary source has timed out and a backup source takes over. If a universe gets its first source,
+//the oldCID is all zeros. The callback is only invoked on changes, not for every packet.
+//Universes that use per-address priorities do not have a single source and do not invoke the callback.
+//The callback runs in its own goroutine, but the changes are delivered one after the other in the
+//order they have happened.
+func (r *ReceiverSocket) SetSourceChangeCallback(callback func(universe uint16, oldCID, newCID [16]byte, priority byte)) {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+	r.sourceChangeCallback = callback
+}
+
+//SetDiscoveryCallback sets the callback for universe discovery packets. Sources announce all
+//universes they are transmitting on every 10 seconds. The callback gets called with the complete list
+//of universes of a source, after all pages of the list were received.
+//Note that the discovery packets are send via multicast, see JoinDiscovery.
+func (r *ReceiverSocket) SetDiscoveryCallback(callback func(cid [16]byte, sourceName string, universes []uint16)) {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+	r.discoveryCallback = callback
+}
+
+//SetStartCodeCallback sets the callback for packets with an alternate start code. Per default, every
+//packet is treated as DMX data regardless of its start code. If a callback is set, only packets with
+//the start code 0x00 (StartCodeDimmer) are treated as DMX data and all packets with other start codes
+//(eg StartCodeText) are given to the callback instead. Packets with per-address priorities (0xDD) are
+//always handled by the receiver itself.
+func (r *ReceiverSocket) SetStartCodeCallback(callback func(p DataPacket)) {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+	r.startCodeCallback = callback
+}
+
+//SetDropPreviewData sets wether packets with the preview_data flag set should be dropped.
+//Sources set this flag for data that is not meant for live output, eg for visualizers.
+//If drop is true, such packets are ignored and no callback is invoked for them.
+//The default is false, so that all packets are processed. Use DataPacket.PreviewData()
+//if you want to inspect the flag yourself.
+func (r *ReceiverSocket) SetDropPreviewData(drop bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.dropPreview = drop
+}
+
+func (r *ReceiverSocket) isDropPreviewData() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.dropPreview
+}
+
+/*
+SetStrictDestination sets wether multicast packets are dropped, if the receiver has not joined their
+multicast-group. On some operating systems a socket that is bound to 0.0.0.0 receives the packets of
+all multicast-groups that are joined by any socket of the host, so that universes leak into receivers
+that never joined them. With strict is true, the destination address of every packet is checked and
+only the multicast-groups of joined universes (see JoinUniverse) are accepted. Unicast packets are
+always accepted.
+
+The destination address is read from the control messages of the socket. If the operating system does
+not support them (eg Windows) or the socket was given to NewReceiverSocketConn and is no UDP socket,
+the destination is unknown and all packets are accepted. The default is false.
+*/
+func (r *ReceiverSocket) SetStrictDestination(strict bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.strictDestination = strict
 }
 
-//SetOnChangeCallback sets the given function as callback for the receiver. If no old DataPacket can
-//be provided, it is a packet with universe 0.
+/*
+SetDeliveryQueue controls how the OnChangeCallback is invoked. By default (size 0) every change gets
+its own goroutine, so a slow callback never stalls the receiver, but if the callback is slower than
+the incoming data, the goroutines pile up without a limit.
+
+With a size greater than 0, the changes are put into a queue with this size and the callback is invoked
+for one change after the other in a single goroutine, so the order of the changes is kept. If the queue
+is full, the policy decides wether the new change (DropNewest) or the oldest change in the queue
+(DropOldest) is dropped. The receiver itself never waits for the callback. The count of dropped changes
+can be obtained via Dropped.
+*/
+func (r *ReceiverSocket) SetDeliveryQueue(size int, policy DropPolicy) {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+	r.stopDelivery()
+	r.dropPolicy = policy
+	if size > 0 {
+		r.queue = make(chan change, size)
+		r.queueDone = make(chan struct{})
+		go r.deliver(r.queue, r.queueDone)
+	}
+}
+
+//SetArbitrationPolicy sets the policy that decides which source is used for a universe, if multiple
+//sources send with the same priority. See ArbitrationPolicy. Default is ErrorOnConflict.
+func (r *ReceiverSocket) SetArbitrationPolicy(policy ArbitrationPolicy) {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+	r.arbitration = policy
+}
+
+//AllowSource adds the source to the allow list. If the allow list is not empty, only the data packets
+//of sources on this list are processed, all other packets are dropped before they are used for
+//arbitration. This protects against rogue or test sources on shared networks.
+func (r *ReceiverSocket) AllowSource(cid [16]byte) {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+	r.allowed[cid] = true
+}
+
+//DenySource adds the source to the deny list. Data packets of sources on this list are dropped before
+//they are used for arbitration, even if the source is on the allow list.
+func (r *ReceiverSocket) DenySource(cid [16]byte) {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+	r.denied[cid] = true
+}
+
+//DropSource removes the source from the universe immediately, eg to stop a misbehaving controller
+//without waiting for the timeout. If the source was used for the universe, the next packet of another
+//source takes over; the data stays unchanged until then. The source is added again, if it continues
+//sending, so use DenySource to ignore it permanently.
+func (r *ReceiverSocket) DropSource(universe uint16, cid [16]byte) {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+	r.removeSource(universe, cid)
+	delete(r.perAddress[universe], cid)
+	if last, ok := r.lastDatas[universe]; ok && last.lastPacket.CID() == cid {
+		last.dropped = true
+		r.lastDatas[universe] = last
+	}
+}
+
+//SetUniverseMap sets a function that patches the universes of received data packets. Every packet is
+//handled as if it was received on the returned universe, so that eg OnData, LastData and the
+//callbacks use this universe. If keep is false, the packet is dropped. Use nil to remove the mapping.
+//The function is called for every data packet by the listener, so it has to return quickly and must
+//not call methods of the receiver.
+//Note that the multicast-groups are still joined with the universe on the wire, so call JoinUniverse
+//with the universe before the mapping.
+func (r *ReceiverSocket) SetUniverseMap(mapping func(in uint16) (out uint16, keep bool)) {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+	r.universeMap = mapping
+}
+
+//SetSequenceCheck sets wether the sequence numbers of received packets are checked. Per default, packets
+//that arrive out of order are dropped as described in E1.31. Disabling the check can be useful for test
+//setups that send with arbitrary sequence numbers, but packets that arrive out of order are then
+//delivered, too. Packets that are received more than once, eg via unicast and multicast, are not
+//detected anymore either, because they are identified by their sequence number.
+func (r *ReceiverSocket) SetSequenceCheck(enabled bool) {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+	r.ignoreSequence = !enabled
+}
+
+/*
+SetDeliverUnchanged sets wether every valid frame of the used source is delivered. Per default, the
+callbacks are only invoked if the data or the priority of a universe has changed, which reduces the
+load for the application. Recorders or monitors that need every frame can enable the delivery of
+unchanged frames. Note that then the callbacks are invoked for every received frame, which is up to
+44 frames per second per universe and at least one per second for the keep alive of the source.
+Frames that are out of order or of a source that is not used for the universe are still dropped.
+*/
+func (r *ReceiverSocket) SetDeliverUnchanged(enabled bool) {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+	r.deliverUnchanged = enabled
+}
+
+/*
+SetInterest restricts the change detection of the universe to the channels from first to last (both
+including, counted from 1). If only a few channels of a universe are patched, eg 1 to 24, changes of
+the other channels do not invoke the callbacks anymore. The delivered packets still contain the data
+of all channels. Changes of the priority are always delivered.
+The default is the full universe, use SetInterest(universe, 1, 512) to reset it. Returns an error, if
+the universe or the channels are invalid.
+*/
+func (r *ReceiverSocket) SetInterest(universe uint16, first, last int) error {
+	if err := checkUniverse(universe); err != nil {
+		return err
+	}
+	if first < 1 || last > 512 || first > last {
+		return fmt.Errorf("the channels %v to %v are not a range in [1-512]", first, last)
+	}
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+	if first == 1 && last == 512 {
+		delete(r.interest, universe)
+	} else {
+		r.interest[universe] = [2]int{first, last}
+	}
+	return nil
+}
+
+//Stats returns a snapshot of the counters of the receiver. The counters are counted from the creation of
+//the receiver on.
+func (r *ReceiverSocket) Stats() Stats {
+	stats := Stats{
+		PacketsReceived: atomic.LoadUint64(&r.stats.packetsReceived),
+		ParseErrors:     atomic.LoadUint64(&r.stats.parseErrors),
+		Delivered:       atomic.LoadUint64(&r.stats.delivered),
+		Suppressed:      atomic.LoadUint64(&r.stats.suppressed),
+		Timeouts:        atomic.LoadUint64(&r.stats.timeouts),
+		Conflicts:       atomic.LoadUint64(&r.stats.conflicts),
+		Sequences:       make(map[uint16]byte),
+	}
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+	for univ, last := range r.lastDatas {
+		stats.Sequences[univ] = last.lastPacket.Sequence()
+	}
+	return stats
+}
+
+//SetMetricsSink sets the sink that gets informed about all events that are counted for Stats. Use nil
+//to remove the sink.
+func (r *ReceiverSocket) SetMetricsSink(sink MetricsSink) {
+	if sink == nil {
+		sink = noopMetrics{}
+	}
+	r.metricsSink.Store(metricsHolder{sink})
+}
+
+func (r *ReceiverSocket) metrics() MetricsSink {
+	return r.metricsSink.Load().(metricsHolder).sink
+}
+
+//SetLogger sets the logger that gets informed about packets that could not be parsed, failed
+//multicast joins, timeouts and a failing socket. Use nil to remove the logger, nothing is logged by
+//default.
+func (r *ReceiverSocket) SetLogger(logger Logger) {
+	r.logger.Store(loggerHolder{logger})
+}
+
+//log returns the Logger or nil, if no logger is set. The arguments of the log calls are only built, if
+//a logger is set.
+func (r *ReceiverSocket) log() Logger {
+	return r.logger.Load().(loggerHolder).logger
+}
+
+//SetRecorder sets the recorder that records all packets that are received by the receiver, before they
+//are parsed. Use nil to stop recording. Errors of the recorder are ignored.
+func (r *ReceiverSocket) SetRecorder(rec *Recorder) {
+	r.recorder.Store(recorderHolder{rec})
+}
+
+//Dropped returns the count of changes that were dropped, because the delivery queue was full.
+//See SetDeliveryQueue.
+func (r *ReceiverSocket) Dropped() uint64 {
+	return atomic.LoadUint64(&r.dropped)
+}
+
+//SetOnChangeCallback sets the given function as callback for the receiver. The callback gets called,
+//if the DMX data or the priority of a universe has changed. If no old DataPacket can be provided, it is
+//a packet with universe 0.
+//Besides the DMX data, the packets carry the information about their source: use CID(), SourceName()
+//and Priority() to find out which source is currently used for the universe.
 func (r *ReceiverSocket) SetOnChangeCallback(callback func(old DataPacket, new DataPacket)) {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
 	r.onChangeCallback = callback
 }
 
-//SetTimeoutCallback sets the callback for timeouts. The callback gets called everytime a timeout is
-//recognized.
+//OnData registers the handler for the data of the given universe. The handler gets called with the new
+//packet, if the data or the priority of the universe has changed, like the OnChangeCallback. Handlers
+//never block the receiver: they run in their own goroutine or in the goroutine of the delivery queue
+//(see SetDeliveryQueue). Only one handler per universe is possible, use nil to remove it.
+func (r *ReceiverSocket) OnData(universe uint16, handler func(p DataPacket)) {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+	if handler == nil {
+		delete(r.dataHandlers, universe)
+		return
+	}
+	r.dataHandlers[universe] = handler
+}
+
+//OnError registers the handler for errors on universes: timeouts (ErrTimeout) and terminated streams
+//(ErrStreamTerminated). The ReceiveError contains the universe and the CID of the source. The handler
+//gets called in its own goroutine, in addition to the TimeoutCallback and TerminationCallback.
+//If the socket can not be read anymore, eg because the network interface is down, ErrInterfaceDown
+//is reported once with universe 0. As soon as the socket works again, all multicast-groups are
+//joined again.
+func (r *ReceiverSocket) OnError(handler func(err ReceiveError)) {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+	r.errorCallback = handler
+}
+
+//SetTimeoutCallback sets the callback for timeouts. The callback gets called once, if a universe times
+//out. It gets called again only after data was received on the universe in the meantime,
+//see SetRecoveredCallback.
 func (r *ReceiverSocket) SetTimeoutCallback(callback func(universe uint16)) {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
 	r.timeoutCallback = callback
 }
+
+//SetRecoveredCallback sets the callback that gets called, if data is received again on a universe
+//after it has timed out. Together with SetTimeoutCallback this can be used to track the state of a
+//universe.
+func (r *ReceiverSocket) SetRecoveredCallback(callback func(universe uint16)) {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+	r.recoveredCallback = callback
+}
+
+//SetIdleTTL sets the time after which the last data of a universe that has timed out is removed. This
+//bounds the memory of receivers that see many transient universes. Universes that are joined, or
+//that are used by OnData, a Universe or WaitForData, are never removed. Use 0 to keep the data of all
+//universes, which is the default.
+func (r *ReceiverSocket) SetIdleTTL(ttl time.Duration) {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+	r.idleTTL = ttl
+}
+
+//SetNewUniverseCallback sets the callback that gets called, if data is received on a universe that
+//had no data before, or whose stream was terminated. The receiver handles the packets of every
+//universe that reaches its socket, eg unicast packets of universes that were never joined, so this
+//can be used to find out which universes are send to this host.
+//Note that the receiver keeps the last data of every universe it has received, see SetIdleTTL.
+func (r *ReceiverSocket) SetNewUniverseCallback(callback func(universe uint16)) {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+	r.newUniverseCallback = callback
+}
+
+//SetTerminationCallback sets the callback for terminated streams. If the source of a universe stops
+//sending and marks its packets with the stream terminated bit, the callback gets called once with the
+//universe. The universe is immediately treated like a universe that never had data, so no timeout
+//is recognized for it afterwards.
+func (r *ReceiverSocket) SetTerminationCallback(callback func(universe uint16)) {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+	r.terminationCallback = callback
+}
@@ -2,82 +2,536 @@ package sacn
 
 import (
 	"bytes"
+	"net"
+	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 //the listener is responsible for listening on the UDP socket and parsing the incoming data.
 //It dispatches the received packets to the corresponding handlers.
 func (r *ReceiverSocket) startListener() {
+	//close the socket as soon as the context is done, so that a blocking read returns immediately
 	go func() {
-		buf := make([]byte, 638)
+		select {
+		case <-r.ctx.Done():
+			r.closeSocket()
+		case <-r.listenerDone:
+		}
+	}()
+	//the helpers invoke callbacks, too, so the listener waits for them before it finishes
+	helpersDone := make(chan struct{})
+	var helpers sync.WaitGroup
+	helpers.Add(2)
+	go func() {
+		defer helpers.Done()
+		r.watchTimeouts(helpersDone)
+	}()
+	go func() {
+		defer helpers.Done()
+		r.sendHeartbeats(helpersDone)
+	}()
+
+	go func() {
+		defer close(r.listenerDone)
+		//one byte more than the biggest packet, so that we can detect truncated packets
+		buf := make([]byte, maxPacketSize+1)
+		failures := 0 //the count of consecutive failed reads
 	Loop:
 		for {
 			select {
 			case <-r.stopListener:
 				break Loop //break if we had a stop signal from the stopChannel
+			case <-r.ctx.Done():
+				break Loop //break if the context of the receiver was cancelled
 			default:
 			}
 
-			r.socket.SetDeadline(time.Now().Add(time.Millisecond * timeoutMs))
-			n, _, addr, _ := r.socket.ReadFrom(buf) //n, ControlMessage, addr, err
-			if addr == nil {                        //Check if we had a timeout
-				//that means we did not receive a packet in 2,5s at all
-				r.checkForTimeouts()
+			r.socket.SetDeadline(time.Now().Add(r.Timeout()))
+			n, dst, addr, err := r.socket.ReadFrom(buf)
+			if netErr, ok := err.(net.Error); err != nil && !(ok && netErr.Timeout()) {
+				//the read failed, eg because the interface is down. Wait before reading again, so that
+				//we do not spin in a tight loop
+				failures++
+				if failures == maxReadFailures {
+					if logger := r.log(); logger != nil {
+						logger.Warn("the socket can not be read", "err", err)
+					}
+					r.stateMu.Lock()
+					r.invokeErrorCallback(0, ErrInterfaceDown)
+					r.stateMu.Unlock()
+				}
+				select {
+				case <-r.stopListener:
+					break Loop
+				case <-r.ctx.Done():
+					break Loop
+				case <-time.After(readBackoff(failures)):
+				}
+				continue
 			}
-			p, err := NewDataPacketRaw(buf[0:n])
+			if failures >= maxReadFailures {
+				//the interface is up again, but the multicast-groups may have been lost
+				r.rejoinGroups()
+			}
+			failures = 0
 			if err != nil {
-				continue //if the packet could not be parsed, just skip it
+				continue //we had a timeout
+			}
+			atomic.AddUint64(&r.stats.packetsReceived, 1)
+			if !r.acceptsDestination(dst) {
+				continue //the multicast-group was not joined by this receiver
+			}
+			if rec := r.recorder.Load().(recorderHolder).rec; rec != nil {
+				rec.Record(time.Now(), buf[0:n])
 			}
-			//send the packet to the responding handler and the other are getting nil
-			r.handle(p)
+			if n > maxPacketSize {
+				//the packet did not fit into the buffer, so it is no valid E1.31 packet
+				r.parseError(addr, errPacketTooLarge)
+				continue
+			}
+			packet, err := ParsePacket(buf[0:n])
+			if err != nil {
+				//if the packet could not be parsed, just skip it
+				r.parseError(addr, err)
+				continue
+			}
+			switch packet.Type {
+			case PacketDiscovery:
+				r.handleDiscovery(packet.Discovery)
+			case PacketSync:
+				r.handleSync(packet.Sync)
+			case PacketData:
+				p := packet.Data
+				r.metrics().IncPackets(p.Universe())
+				p.source = addr
+				p.dest = dst
+				//handle the packet inline, so that the packets of a universe are processed in arrival order
+				r.handle(p)
+			}
+		}
+		close(helpersDone)
+		helpers.Wait()
+		if r.ctx.Err() != nil {
+			//the context was cancelled, so the receiver is closed from now on
+			r.mu.Lock()
+			r.closed = true
+			r.mu.Unlock()
+			r.closeSocket()
+			r.stateMu.Lock()
+			r.stopDelivery()
+			r.stateMu.Unlock()
 		}
-		r.socket.Close()     //close the channel, if the listener is finished
-		r.stopListener = nil //set the channel to nil, so it can be used as indicator if the routine is running
 	}()
 }
 
+//watchTimeouts checks for timeouts timeoutChecks times per timeout interval until done is closed, so
+//that timeouts are detected independent of the arrival of packets. A timeout is reported at most a
+//quarter of the timeout late.
+func (r *ReceiverSocket) watchTimeouts(done <-chan struct{}) {
+	timer := time.NewTimer(r.Timeout() / timeoutChecks)
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C:
+		case <-done:
+			return
+		}
+		r.stateMu.Lock()
+		r.checkForTimeouts()
+		r.stateMu.Unlock()
+		timer.Reset(r.Timeout() / timeoutChecks) //the timeout may have been changed
+	}
+}
+
+//sendHeartbeats delivers the last data of all receiving universes with the heartbeat interval until
+//done is closed. See SetHeartbeatInterval.
+func (r *ReceiverSocket) sendHeartbeats(done <-chan struct{}) {
+	for {
+		var tick <-chan time.Time //nil, so that it blocks forever if the heartbeat is off
+		if interval := r.HeartbeatInterval(); interval > 0 {
+			tick = time.After(interval)
+		}
+		select {
+		case <-tick:
+			r.stateMu.Lock()
+			for univ, last := range r.lastDatas {
+				if !r.timeoutCalled[univ] && r.now().Sub(last.lastTime) <= r.Timeout() {
+					r.invokeCallback(last.lastPacket.copy())
+				}
+			}
+			r.stateMu.Unlock()
+		case <-r.heartbeatChanged:
+		case <-done:
+			return
+		}
+	}
+}
+
+//readBackoff returns the time to wait after the given count of consecutive failed reads. It doubles
+//with every failure, starting with 10ms up to one second.
+func readBackoff(failures int) time.Duration {
+	if failures > 7 {
+		return time.Second
+	}
+	return 10 * time.Millisecond << uint(failures-1)
+}
+
+//rejoinGroups joins all multicast-groups again, after the interface was down
+func (r *ReceiverSocket) rejoinGroups() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if logger := r.log(); logger != nil {
+		logger.Info("the socket can be read again")
+	}
+	if r.multicastInterface == nil {
+		return
+	}
+	for universe := range r.joined {
+		r.socket.LeaveGroup(r.multicastInterface, universe)
+		if err := r.socket.JoinGroup(r.multicastInterface, universe); err != nil {
+			if logger := r.log(); logger != nil {
+				logger.Warn("could not join multicast-group", "universe", universe, "err", err)
+			}
+		}
+	}
+}
+
+//parseError counts a packet that could not be parsed and logs the reason
+func (r *ReceiverSocket) parseError(addr net.Addr, err error) {
+	atomic.AddUint64(&r.stats.parseErrors, 1)
+	r.metrics().IncParseErrors()
+	if logger := r.log(); logger != nil {
+		logger.Debug("invalid packet", "addr", addr, "err", err)
+	}
+}
+
 //the handler is responsible for checking all necessary things to decide if callbacks should be invoked
 func (r *ReceiverSocket) handle(p DataPacket) {
-	r.checkForTimeouts()
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+	if !r.isSourceAccepted(p.CID()) {
+		return
+	}
+	if !r.ignoreSequence && r.dedup.isDuplicate(p, r.now()) {
+		//the same packet was received via another way, eg via unicast and multicast. Without the
+		//sequence check the sequence numbers may repeat, so they can not identify duplicates
+		atomic.AddUint64(&r.stats.suppressed, 1)
+		r.metrics().IncSuppressed(p.Universe())
+		return
+	}
+	if r.universeMap != nil {
+		universe, keep := r.universeMap(p.Universe())
+		if !keep {
+			return
+		}
+		p.SetUniverse(universe)
+	}
+	if p.Priority() > MaxPriority {
+		//a misbehaving source must not win the arbitration with an invalid priority
+		if logger := r.log(); logger != nil {
+			logger.Debug("priority out of range", "universe", p.Universe(), "cid", CIDString(p.CID()),
+				"priority", p.Priority())
+		}
+		p.data[108] = MaxPriority
+	}
+	if p.StreamTerminated() {
+		r.removeSource(p.Universe(), p.CID())
+		//forget the per-address state, so that a restarted stream of the source is not out of order
+		delete(r.perAddress[p.Universe()], p.CID())
+		r.handleTermination(p)
+		return
+	}
+	if p.PreviewData() && r.isDropPreviewData() {
+		return
+	}
+	r.updateSource(p)
+	if p.SyncAddress() != 0 && r.holdForSync(p) {
+		return
+	}
+	r.processCounted(p)
+}
+
+//isSourceAccepted returns true, if the packets of the source pass the allow and deny list.
+//The caller has to hold the stateMu.
+func (r *ReceiverSocket) isSourceAccepted(cid [16]byte) bool {
+	if r.denied[cid] {
+		return false
+	}
+	return len(r.allowed) == 0 || r.allowed[cid]
+}
+
+//processCounted processes the packet and counts it as suppressed, if it did not lead to a change
+func (r *ReceiverSocket) processCounted(p DataPacket) {
+	//only invokeCallback counts the delivered changes and it is guarded by the stateMu like we are
+	delivered := atomic.LoadUint64(&r.stats.delivered)
+	r.process(p)
+	if atomic.LoadUint64(&r.stats.delivered) == delivered {
+		atomic.AddUint64(&r.stats.suppressed, 1)
+		r.metrics().IncSuppressed(p.Universe())
+	}
+}
+
+//process checks the sequence and the priority of the packet and invokes the callback, if the data
+//or the priority has changed
+func (r *ReceiverSocket) process(p DataPacket) {
+	if p.IsPerAddressPriority() {
+		r.handlePerAddressPriority(p)
+		return
+	}
+	if p.DmxStartCode() != StartCodeDimmer && r.startCodeCallback != nil {
+		go r.startCodeCallback(p)
+		return
+	}
+	if r.usesPerAddressPriority(p.Universe()) {
+		r.processPerAddress(p)
+		return
+	}
 	//check if we had a change in priority to the last data we received on the universe
 	last, ok := r.lastDatas[p.Universe()]
 	if ok {
 		//check if the last packet is too long ago, then we do not have to check all other things
-		if time.Since(last.lastTime) > time.Millisecond*timeoutMs {
-			//invoke callback and store the new packet and time
-			if !bytes.Equal(last.lastPacket.Data(), p.Data()) {
+		if r.now().Sub(last.lastTime) > r.Timeout() {
+			//the universe had no data, so this is the first frame and it is always delivered, even if
+			//it equals the last data before the timeout
+			r.invokeCallback(p)
+			r.storeWinningPacket(p)
+			return // we are finished with this packet
+		}
+		if last.dropped ||
+			last.lastPacket.Priority() > p.Priority() && r.now().Sub(last.lastTime) > r.PriorityTimeout() {
+			//the source of the last packet was dropped or the source with the higher priority was
+			//silent for the priority timeout, so this source takes over
+			if r.isDelivered(last.lastPacket, p) {
 				r.invokeCallback(p)
 			}
-			r.storeLastPacket(p)
-			return // we are finished with this packet
+			r.storeWinningPacket(p)
+			return
 		}
 		//we have last data for this universe, so check the priority
 		if last.lastPacket.Priority() == p.Priority() {
 			//we have the same priority
+			if lastCID := last.lastPacket.CID(); lastCID != p.CID() {
+				//another source with the same priority: the policy decides which one is used
+				atomic.AddUint64(&r.stats.conflicts, 1)
+				r.metrics().IncConflicts(p.Universe())
+				if r.arbitration == ErrorOnConflict {
+					r.reportConflict(p.Universe(), lastCID, p.CID())
+				}
+				if r.arbitrate(lastCID, p.CID()) {
+					if r.isDelivered(last.lastPacket, p) {
+						r.invokeCallback(p)
+					}
+					r.storeWinningPacket(p)
+				}
+				return
+			}
 			//check sequence:
-			if checkSequ(last.lastPacket.Sequence(), p.Sequence()) {
+			if r.checkSequence(last.lastPacket, p) {
 				//sequence is good:; check if the data has changed. If so, then invoke callback
-				if !bytes.Equal(last.lastPacket.Data(), p.Data()) {
+				if r.isDelivered(last.lastPacket, p) {
 					r.invokeCallback(p)
 				}
-				r.storeLastPacket(p)
+				r.storeWinningPacket(p)
 			}
 		} else if last.lastPacket.Priority() < p.Priority() {
 			//priority is higher: invoke callback on data change
-			if !bytes.Equal(last.lastPacket.Data(), p.Data()) {
+			if r.isDelivered(last.lastPacket, p) {
 				r.invokeCallback(p)
 			}
 			//store the new packet regardless
-			r.storeLastPacket(p)
+			r.storeWinningPacket(p)
 		}
 	} else {
 		//store new packet and invoke callback, because we never had data on this one
 		r.invokeCallback(p)
-		r.storeLastPacket(p)
+		r.storeWinningPacket(p)
+	}
+}
+
+//checkSequence returns true, if the new packet is in order after the last packet of the same source
+func (r *ReceiverSocket) checkSequence(last, p DataPacket) bool {
+	return r.ignoreSequence || checkSequ(last.Sequence(), p.Sequence())
+}
+
+//isDelivered returns true, if the new packet is delivered to the callbacks. This is the case if the
+//data or the priority has changed or if unchanged frames are delivered, see SetDeliverUnchanged.
+func (r *ReceiverSocket) isDelivered(old, new DataPacket) bool {
+	return r.deliverUnchanged || old.Priority() != new.Priority() ||
+		r.dataChanged(new.Universe(), old.Data(), new.Data())
+}
+
+//dataChanged returns true, if the data has changed within the channels of interest of the universe.
+//See SetInterest.
+func (r *ReceiverSocket) dataChanged(universe uint16, old, new []byte) bool {
+	if interest, ok := r.interest[universe]; ok {
+		return !bytes.Equal(channelRange(old, interest), channelRange(new, interest))
+	}
+	return !bytes.Equal(old, new)
+}
+
+//channelRange returns the data of the channels from interest[0] to interest[1]. It is shorter, if the
+//data does not contain all of these channels.
+func channelRange(data []byte, interest [2]int) []byte {
+	if interest[0] > len(data) {
+		return nil
+	}
+	if interest[1] > len(data) {
+		return data[interest[0]-1:]
+	}
+	return data[interest[0]-1 : interest[1]]
+}
+
+//arbitrate returns true, if the source with the new CID should be used instead of the current source.
+//Both sources send with the same priority.
+func (r *ReceiverSocket) arbitrate(current, new [16]byte) bool {
+	switch r.arbitration {
+	case FirstReceived, ErrorOnConflict:
+		return false
+	case HighestCID:
+		return bytes.Compare(new[:], current[:]) > 0
+	default:
+		return true
+	}
+}
+
+//reportConflict reports ErrSourcesExceeded for the source that sends with the same priority as the
+//used source of the universe. Every conflicting source is only reported once, until it is removed.
+func (r *ReceiverSocket) reportConflict(universe uint16, current, conflicting [16]byte) {
+	info, ok := r.sources[universe][conflicting]
+	if !ok || info.conflictReported {
+		return
 	}
+	info.conflictReported = true
+	r.sources[universe][conflicting] = info
+	if logger := r.log(); logger != nil {
+		logger.Warn("sources exceeded", "universe", universe, "cid", CIDString(current),
+			"conflicting", CIDString(conflicting))
+	}
+	r.invokeErrorCallback(universe, ErrSourcesExceeded, current, conflicting)
+}
+
+//updateSource stores the information about the source of the packet for Sources
+func (r *ReceiverSocket) updateSource(p DataPacket) {
+	sources, ok := r.sources[p.Universe()]
+	if !ok {
+		sources = make(map[[16]byte]SourceInfo)
+		r.sources[p.Universe()] = sources
+	}
+	info := sources[p.CID()]
+	info.CID = p.CID()
+	info.SourceName = p.SourceName()
+	if !p.IsPerAddressPriority() {
+		info.Priority = p.Priority()
+	}
+	info.LastSeen = r.now()
+	if p.DmxStartCode() == StartCodeDimmer {
+		info.Timing.add(info.LastSeen)
+	}
+	sources[p.CID()] = info
 }
 
-//invokeCallback calls the callback if it is present.
+//removeSource removes the source from the list of the sources of the universe
+func (r *ReceiverSocket) removeSource(universe uint16, cid [16]byte) {
+	delete(r.sources[universe], cid)
+	if len(r.sources[universe]) == 0 {
+		delete(r.sources, universe)
+	}
+}
+
+//holdForSync stores the packet until the sync packet for its sync address arrives. Returns false,
+//if the packet should be processed immediately. This is the case if the synchronization was lost
+//and the source has set the force_synchronization flag.
+func (r *ReceiverSocket) holdForSync(p DataPacket) bool {
+	sync := p.SyncAddress()
+	if p.ForceSync() && r.now().Sub(r.lastSync[sync]) > r.Timeout() {
+		return false
+	}
+	held, ok := r.syncHeld[sync]
+	if !ok {
+		held = &heldData{since: r.now(), packets: make(map[uint16]DataPacket)}
+		r.syncHeld[sync] = held
+	}
+	held.packets[p.Universe()] = p
+	return true
+}
+
+//handleSync processes all data that was held for the sync address of the packet. Sync packets have
+//their own sequence numbers per source and sync address, out of order sync packets are ignored.
+func (r *ReceiverSocket) handleSync(p SyncPacket) {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+	key := syncSource{p.CID(), p.SyncAddress()}
+	if last, ok := r.syncSequences[key]; ok && r.now().Sub(last.time) <= r.Timeout() &&
+		!r.ignoreSequence && !checkSequ(last.sequence, p.Sequence()) {
+		return
+	}
+	r.syncSequences[key] = syncState{p.Sequence(), r.now()}
+	r.lastSync[p.SyncAddress()] = r.now()
+	r.releaseHeld(p.SyncAddress())
+}
+
+//releaseHeld processes all data that was held for the given sync address
+func (r *ReceiverSocket) releaseHeld(sync uint16) {
+	held, ok := r.syncHeld[sync]
+	if !ok {
+		return
+	}
+	delete(r.syncHeld, sync)
+	for _, p := range held.packets {
+		r.processCounted(p)
+	}
+}
+
+//handleTermination removes the universe, if the packet with the stream terminated bit was sent by
+//the source we currently use for this universe. A source sends three of those packets, but only the
+//first one removes the universe and invokes the callback.
+func (r *ReceiverSocket) handleTermination(p DataPacket) {
+	last, ok := r.lastDatas[p.Universe()]
+	if !ok || last.lastPacket.CID() != p.CID() {
+		return
+	}
+	delete(r.lastDatas, p.Universe())
+	delete(r.timeoutCalled, p.Universe())
+	if r.terminationCallback != nil {
+		go r.terminationCallback(p.Universe())
+	}
+	r.invokeErrorCallback(p.Universe(), ErrStreamTerminated, p.CID())
+}
+
+//handleDiscovery collects the pages of the universe discovery of a source. If all pages have been
+//received, the discovery callback is invoked with all universes of the source.
+func (r *ReceiverSocket) handleDiscovery(p DiscoveryPacket) {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+	if p.Page() > p.LastPage() {
+		return //invalid page number
+	}
+	pages, ok := r.discoveryPages[p.CID()]
+	//if the number of pages has changed, the old pages are outdated
+	if !ok || pages.lastPage != p.LastPage() {
+		pages = discoveryPages{lastPage: p.LastPage(), pages: make(map[byte]DiscoveryPacket)}
+		r.discoveryPages[p.CID()] = pages
+	}
+	pages.pages[p.Page()] = p
+	if len(pages.pages) <= int(p.LastPage()) {
+		return //wait for the remaining pages
+	}
+	delete(r.discoveryPages, p.CID())
+
+	universes := make([]uint16, 0)
+	for i := 0; i <= int(p.LastPage()); i++ {
+		page := pages.pages[byte(i)]
+		universes = append(universes, page.Universes()...)
+	}
+	if r.discoveryCallback != nil {
+		go r.discoveryCallback(p.CID(), p.SourceName(), universes)
+	}
+}
+
+//invokeCallback calls the callback if it is present. If a delivery queue is used, the change is put
+//into the queue instead.
 func (r *ReceiverSocket) invokeCallback(new DataPacket) {
 	oldData, ok := r.lastDatas[new.Universe()]
 	var old DataPacket
@@ -86,29 +540,286 @@ func (r *ReceiverSocket) invokeCallback(new DataPacket) {
 	} else {
 		old = NewDataPacket()
 	}
+	atomic.AddUint64(&r.stats.delivered, 1)
+	r.metrics().IncDelivered(new.Universe())
+	if r.queue != nil {
+		r.enqueue(change{old, new})
+		return
+	}
 	if r.onChangeCallback != nil {
 		go r.onChangeCallback(old, new)
 	}
+	if handler := r.dataHandlers[new.Universe()]; handler != nil {
+		go handler(new)
+	}
+}
+
+//invokeErrorCallback calls the error callback if it is present
+func (r *ReceiverSocket) invokeErrorCallback(universe uint16, err error, cids ...[16]byte) {
+	if r.errorCallback != nil {
+		go r.errorCallback(ReceiveError{Universe: universe, CIDs: cids, Err: err})
+	}
+}
+
+//enqueue puts the change into the delivery queue without blocking. If the queue is full, a change is
+//dropped according to the drop policy.
+func (r *ReceiverSocket) enqueue(c change) {
+	select {
+	case r.queue <- c:
+		return
+	default:
+	}
+	if r.dropPolicy == DropOldest {
+		select {
+		case <-r.queue: //make room for the new change
+			atomic.AddUint64(&r.dropped, 1)
+		default:
+		}
+		select {
+		case r.queue <- c:
+			return
+		default:
+		}
+	}
+	atomic.AddUint64(&r.dropped, 1)
+}
+
+//deliver invokes the callback for every change in the queue, until the queue is closed. Closes done
+//after the last change was delivered.
+func (r *ReceiverSocket) deliver(queue <-chan change, done chan<- struct{}) {
+	defer close(done)
+	for c := range queue {
+		r.stateMu.Lock()
+		callback := r.onChangeCallback
+		handler := r.dataHandlers[c.new.Universe()]
+		r.stateMu.Unlock()
+		if callback != nil {
+			callback(c.old, c.new)
+		}
+		if handler != nil {
+			handler(c.new)
+		}
+	}
+}
+
+//stopDelivery closes the delivery queue, so that the delivering goroutine stops after all changes
+//in the queue were delivered. The caller has to hold the stateMu.
+func (r *ReceiverSocket) stopDelivery() {
+	if r.queue != nil {
+		close(r.queue)
+		r.queue = nil
+	}
+}
+
+//storeWinningPacket stores the packet of the source that is used for the universe. If the source has
+//changed, the SourceChangeCallback is invoked.
+func (r *ReceiverSocket) storeWinningPacket(p DataPacket) {
+	last, ok := r.lastDatas[p.Universe()]
+	var oldCID [16]byte
+	if ok {
+		oldCID = last.lastPacket.CID()
+	}
+	if (!ok || oldCID != p.CID()) && r.sourceChangeCallback != nil {
+		r.queueSourceChange(sourceChange{r.sourceChangeCallback, p.Universe(), oldCID, p.CID(), p.Priority()})
+	}
+	r.storeLastPacket(p)
+}
+
+//queueSourceChange queues the source change for the SourceChangeCallback. The changes are delivered
+//by one goroutine at a time, so they keep their order. The caller has to hold the stateMu.
+func (r *ReceiverSocket) queueSourceChange(c sourceChange) {
+	r.sourceChanges = append(r.sourceChanges, c)
+	if len(r.sourceChanges) == 1 {
+		//no goroutine is delivering at the moment
+		go r.deliverSourceChanges()
+	}
+}
+
+//deliverSourceChanges invokes the callback for the queued source changes, until the queue is empty
+func (r *ReceiverSocket) deliverSourceChanges() {
+	for {
+		r.stateMu.Lock()
+		c := r.sourceChanges[0]
+		r.stateMu.Unlock()
+		c.callback(c.universe, c.oldCID, c.newCID, c.priority)
+		r.stateMu.Lock()
+		r.sourceChanges = r.sourceChanges[1:]
+		empty := len(r.sourceChanges) == 0
+		if empty {
+			r.sourceChanges = nil
+		}
+		r.stateMu.Unlock()
+		if empty {
+			return
+		}
+	}
 }
 
-//storeLastPacket stores the packet in the lastDatas store
+//storeLastPacket stores the packet in the lastDatas store and hands it to the waiting WaitForData calls
+//and the Universes
 func (r *ReceiverSocket) storeLastPacket(p DataPacket) {
+	if _, ok := r.lastDatas[p.Universe()]; !ok && r.newUniverseCallback != nil {
+		go r.newUniverseCallback(p.Universe())
+	}
 	r.lastDatas[p.Universe()] = lastData{
 		lastPacket: p.copy(),
-		lastTime:   time.Now(),
+		lastTime:   r.now(),
+	}
+	if r.timeoutCalled[p.Universe()] && r.recoveredCallback != nil {
+		go r.recoveredCallback(p.Universe())
 	}
 	r.timeoutCalled[p.Universe()] = false
+	//the channels are buffered and only used once, so this does not block
+	for _, waiter := range r.waiters[p.Universe()] {
+		waiter <- p.copy()
+	}
+	delete(r.waiters, p.Universe())
+	for _, view := range r.views[p.Universe()] {
+		view.update(p)
+	}
 }
 
 //checkForTimeouts checks all last data if a universe had a timeout. Calls the timeoutCallback.
+//Data that was held for a sync packet longer than the timeout is released.
+//The caller has to hold the stateMu.
 func (r *ReceiverSocket) checkForTimeouts() {
+	for sync, held := range r.syncHeld {
+		if r.now().Sub(held.since) > r.Timeout() {
+			//no sync packet arrived, so the held data is processed without synchronization
+			if logger := r.log(); logger != nil {
+				logger.Warn("synchronization lost", "universe", sync)
+			}
+			r.invokeErrorCallback(sync, ErrSyncLost)
+			r.releaseHeld(sync)
+		}
+	}
+	for key, last := range r.syncSequences {
+		if r.now().Sub(last.time) > r.Timeout() {
+			delete(r.syncSequences, key)
+		}
+	}
+	r.removePerAddressTimeouts()
+	for univ, sources := range r.sources {
+		for cid, info := range sources {
+			if r.now().Sub(info.LastSeen) > r.Timeout() {
+				r.removeSource(univ, cid)
+			}
+		}
+	}
 	for univ, last := range r.lastDatas {
-		if time.Since(last.lastTime) > time.Millisecond*timeoutMs {
+		if r.now().Sub(last.lastTime) > r.Timeout() {
 			//timeout
-			if r.timeoutCallback != nil && !r.timeoutCalled[univ] {
+			//only invoke the callback once on the transition to timed out
+			if r.timeoutCalled[univ] {
+				continue
+			}
+			r.timeoutCalled[univ] = true
+			atomic.AddUint64(&r.stats.timeouts, 1)
+			r.metrics().IncTimeouts(univ)
+			if logger := r.log(); logger != nil {
+				logger.Info("universe timed out", "universe", univ, "cid", CIDString(last.lastPacket.CID()))
+			}
+			if r.timeoutCallback != nil {
 				go r.timeoutCallback(univ)
-				r.timeoutCalled[univ] = true
 			}
+			r.invokeErrorCallback(univ, ErrTimeout, last.lastPacket.CID())
+		}
+	}
+	r.removeIdle()
+}
+
+//removeIdle removes the data of timed out universes that had no data for longer than the idle TTL and
+//that are not used. The caller has to hold the stateMu.
+func (r *ReceiverSocket) removeIdle() {
+	if r.idleTTL <= 0 {
+		return
+	}
+	for univ, last := range r.lastDatas {
+		if !r.timeoutCalled[univ] || r.now().Sub(last.lastTime) <= r.idleTTL || r.isUsed(univ) {
+			continue
+		}
+		r.removeUniverse(univ)
+	}
+}
+
+//removeUniverse removes all stored data of the universe. The caller has to hold the stateMu.
+func (r *ReceiverSocket) removeUniverse(universe uint16) {
+	delete(r.lastDatas, universe)
+	delete(r.timeoutCalled, universe)
+	delete(r.sources, universe)
+	delete(r.perAddress, universe)
+}
+
+//applyActiveUniverses joins the multicast-groups of all universes of the set that are not joined yet
+//and leaves all other joined groups, in ascending order. It returns the universes that failed and the
+//universes that were left.
+func (r *ReceiverSocket) applyActiveUniverses(set map[uint16]struct{}) (failed map[uint16]error, left []uint16) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	failed = make(map[uint16]error)
+	var join []uint16
+	for universe := range r.joined {
+		if _, ok := set[universe]; !ok && universe != discoveryUniverse {
+			left = append(left, universe)
+		}
+	}
+	for universe := range set {
+		if !r.joined[universe] {
+			join = append(join, universe)
+		}
+	}
+	sort.Slice(left, func(i, j int) bool { return left[i] < left[j] })
+	sort.Slice(join, func(i, j int) bool { return join[i] < join[j] })
+	for _, universe := range left {
+		if r.multicastInterface == nil {
+			delete(r.joined, universe) //without an interface, no group can be joined
+			continue
+		}
+		if err := r.socket.LeaveGroup(r.multicastInterface, universe); err != nil {
+			failed[universe] = err
+			continue
+		}
+		delete(r.joined, universe)
+	}
+	for _, universe := range join {
+		if r.multicastInterface == nil {
+			failed[universe] = ErrNoMulticastInterface
+			continue
+		}
+		if err := r.socket.JoinGroup(r.multicastInterface, universe); err != nil {
+			if logger := r.log(); logger != nil {
+				logger.Warn("could not join multicast-group", "universe", universe, "err", err)
+			}
+			failed[universe] = err
+			continue
 		}
+		r.joined[universe] = true
+	}
+	return failed, left
+}
+
+//acceptsDestination returns false, if strict destination filtering is on and dst is a multicast-group
+//that was not joined. See SetStrictDestination.
+func (r *ReceiverSocket) acceptsDestination(dst net.IP) bool {
+	if dst == nil || !dst.IsMulticast() {
+		return true
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.strictDestination {
+		return true
+	}
+	universe, ok := multicastGroupUniverse(dst)
+	return ok && r.joined[universe]
+}
+
+//isUsed returns true, if the universe is joined or used by OnData, a Universe or WaitForData.
+//The caller has to hold the stateMu.
+func (r *ReceiverSocket) isUsed(universe uint16) bool {
+	if r.dataHandlers[universe] != nil || len(r.views[universe]) > 0 || len(r.waiters[universe]) > 0 {
+		return true
 	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.joined[universe]
 }
@@ -0,0 +1,1754 @@
+package sacn
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+//newTestReceiver creates a receiver without a socket, so that the handler can be tested directly
+func newTestReceiver() *ReceiverSocket {
+	return newReceiverSocket(context.Background(), nil)
+}
+
+//fakeClock is a clock for the receiver that only moves forward if it is advanced
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+//newTestReceiverClock creates a receiver like newTestReceiver, that uses the returned clock
+func newTestReceiverClock() (*ReceiverSocket, *fakeClock) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	r := newTestReceiver()
+	r.now = clock.Now
+	return r, clock
+}
+
+func TestReceiverTimeout(t *testing.T) {
+	r := newTestReceiver()
+	if r.Timeout() != 2500*time.Millisecond {
+		t.Errorf("Default timeout should be 2.5s, was %v", r.Timeout())
+	}
+	r.SetTimeout(10 * time.Millisecond)
+	if r.Timeout() != 10*time.Millisecond {
+		t.Errorf("Timeout was not set! Was %v", r.Timeout())
+	}
+	for _, timeout := range []time.Duration{0, -time.Second} {
+		if err := r.SetTimeout(timeout); err == nil {
+			t.Errorf("Timeout %v should not be accepted", timeout)
+		}
+		if r.Timeout() != 10*time.Millisecond {
+			t.Errorf("Timeout %v has changed the timeout to %v", timeout, r.Timeout())
+		}
+	}
+
+	timeouts := make(chan uint16, 1)
+	r.SetTimeoutCallback(func(univ uint16) {
+		timeouts <- univ
+	})
+	p := NewDataPacket()
+	p.SetUniverse(5)
+	r.handle(p)
+	time.Sleep(20 * time.Millisecond)
+	r.checkForTimeouts()
+	select {
+	case univ := <-timeouts:
+		if univ != 5 {
+			t.Errorf("Timeout was on the wrong universe! Was %v", univ)
+		}
+	case <-time.After(time.Second):
+		t.Error("Timeout callback was not called with the shorter timeout")
+	}
+}
+
+func TestWatchTimeouts(t *testing.T) {
+	r := newTestReceiver()
+	r.SetTimeout(20 * time.Millisecond)
+	timeouts := make(chan uint16, 1)
+	r.SetTimeoutCallback(func(univ uint16) {
+		timeouts <- univ
+	})
+	done := make(chan struct{})
+	defer close(done)
+	go r.watchTimeouts(done)
+	p := NewDataPacket()
+	p.SetUniverse(5)
+	r.handle(p)
+	//no further packet is needed to detect the timeout
+	select {
+	case univ := <-timeouts:
+		if univ != 5 {
+			t.Errorf("Timeout was on the wrong universe! Was %v", univ)
+		}
+	case <-time.After(time.Second):
+		t.Error("Timeout was not detected without packets!")
+	}
+}
+
+func TestHeartbeatAfterClose(t *testing.T) {
+	//the heartbeat goroutine must have stopped, before Close returns
+	for i := 0; i < 20; i++ {
+		r := newTestReceiver()
+		r.socket = &fakeConn{}
+		r.OnData(1, func(p DataPacket) {})
+		p := NewDataPacket()
+		p.SetUniverse(1)
+		r.handle(p)
+		r.SetHeartbeatInterval(time.Millisecond)
+		r.Start()
+		time.Sleep(time.Duration(i%4) * time.Millisecond)
+		r.Close()
+		delivered := r.Stats().Delivered
+		time.Sleep(5 * time.Millisecond)
+		if r.Stats().Delivered != delivered {
+			t.Fatal("A heartbeat was delivered after Close has returned!")
+		}
+	}
+}
+
+func TestHeartbeat(t *testing.T) {
+	r := newTestReceiver()
+	delivered := make(chan DataPacket, 100)
+	r.OnData(1, func(p DataPacket) {
+		delivered <- p
+	})
+	done := make(chan struct{})
+	defer close(done)
+	go r.sendHeartbeats(done)
+	p := NewDataPacket()
+	p.SetUniverse(1)
+	p.SetData([]byte{7})
+	r.handle(p)
+	<-delivered
+
+	//the heartbeat is off per default
+	time.Sleep(30 * time.Millisecond)
+	if len(delivered) != 0 {
+		t.Fatal("Unchanged data should not be delivered without heartbeat!")
+	}
+	r.SetHeartbeatInterval(5 * time.Millisecond)
+	if r.HeartbeatInterval() != 5*time.Millisecond {
+		t.Fatalf("Heartbeat interval was not set! Was %v", r.HeartbeatInterval())
+	}
+	for i := 0; i < 3; i++ {
+		select {
+		case got := <-delivered:
+			if got.Data()[0] != 7 {
+				t.Errorf("Heartbeat has the wrong data! Was: %v", got.Data())
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Heartbeat was not delivered!")
+		}
+	}
+
+	//terminated universes get no heartbeat
+	p = p.copy()
+	p.SequenceIncr()
+	p.SetStreamTerminated(true)
+	r.handle(p)
+	time.Sleep(10 * time.Millisecond)
+	for len(delivered) > 0 {
+		<-delivered
+	}
+	time.Sleep(30 * time.Millisecond)
+	if len(delivered) != 0 {
+		t.Error("Terminated universe should not get a heartbeat!")
+	}
+}
+
+func TestHandleStreamTerminated(t *testing.T) {
+	r := newTestReceiver()
+	terminated := make(chan uint16, 3)
+	r.SetTerminationCallback(func(univ uint16) {
+		terminated <- univ
+	})
+	p := NewDataPacket()
+	p.SetUniverse(1)
+	p.SetCID([16]byte{1})
+	r.handle(p)
+
+	//a packet from another source should not terminate the universe
+	other := p.copy()
+	other.SetCID([16]byte{2})
+	other.SetStreamTerminated(true)
+	r.handle(other)
+	if _, ok := r.lastDatas[1]; !ok {
+		t.Error("Universe was removed by a termination of another source!")
+	}
+
+	p.SetStreamTerminated(true)
+	for i := 0; i < 3; i++ {
+		p.SequenceIncr()
+		r.handle(p)
+	}
+	if _, ok := r.lastDatas[1]; ok {
+		t.Error("Universe should have been removed after termination!")
+	}
+	time.Sleep(50 * time.Millisecond)
+	if len(terminated) != 1 {
+		t.Errorf("Termination callback should have been called once, was %v times", len(terminated))
+	}
+}
+
+func TestHandleDropPreviewData(t *testing.T) {
+	r := newTestReceiver()
+	changed := make(chan DataPacket, 10)
+	r.SetOnChangeCallback(func(old, new DataPacket) {
+		changed <- new
+	})
+	r.SetDropPreviewData(true)
+	p := NewDataPacket()
+	p.SetUniverse(1)
+	p.SetPreviewData(true)
+	r.handle(p)
+	if _, ok := r.lastDatas[1]; ok {
+		t.Error("Preview packet should have been dropped!")
+	}
+
+	p = p.copy()
+	p.SetPreviewData(false)
+	p.SequenceIncr()
+	r.handle(p)
+	if _, ok := r.lastDatas[1]; !ok {
+		t.Error("Packet without preview flag should have been processed!")
+	}
+	select {
+	case got := <-changed:
+		if got.PreviewData() {
+			t.Error("Packet should not have the preview flag set!")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Callback was not called!")
+	}
+
+	r.SetDropPreviewData(false)
+	p = p.copy()
+	p.SetPreviewData(true)
+	p.SequenceIncr()
+	p.SetData([]byte{1})
+	r.handle(p)
+	select {
+	case got := <-changed:
+		if !got.PreviewData() {
+			t.Error("Preview flag should be visible to the callback!")
+		}
+	case <-time.After(time.Second):
+		t.Error("Preview packet should have been processed, if they are not dropped!")
+	}
+}
+
+func TestHandleDiscovery(t *testing.T) {
+	r := newTestReceiver()
+	type discovery struct {
+		name      string
+		universes []uint16
+	}
+	discovered := make(chan discovery, 10)
+	r.SetDiscoveryCallback(func(cid [16]byte, sourceName string, universes []uint16) {
+		discovered <- discovery{sourceName, universes}
+	})
+	univs := make([]uint16, 600)
+	for i := range univs {
+		univs[i] = uint16(i + 1)
+	}
+	packets := buildDiscoveryPackets([16]byte{1}, "test", univs)
+	r.handleDiscovery(packets[1])
+	select {
+	case <-discovered:
+		t.Fatal("Callback should not be called before all pages were received!")
+	case <-time.After(50 * time.Millisecond):
+	}
+	r.handleDiscovery(packets[0])
+	select {
+	case d := <-discovered:
+		if d.name != "test" || len(d.universes) != 600 || d.universes[0] != 1 || d.universes[599] != 600 {
+			t.Errorf("Wrong discovery! Was: %v, %v universes", d.name, len(d.universes))
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Callback was not called after all pages were received!")
+	}
+	if len(r.discoveryPages) != 0 {
+		t.Error("Pages should have been removed after the callback!")
+	}
+}
+
+func TestHandleSync(t *testing.T) {
+	r := newTestReceiver()
+	changed := make(chan DataPacket, 10)
+	r.SetOnChangeCallback(func(old, new DataPacket) {
+		changed <- new
+	})
+	p := NewDataPacket()
+	p.SetUniverse(1)
+	p.SetSyncAddress(7)
+	r.handle(p)
+	if _, ok := r.lastDatas[1]; ok {
+		t.Fatal("Packet should have been held until the sync packet arrives!")
+	}
+	sync := NewSyncPacket()
+	sync.SetSyncAddress(8)
+	r.handleSync(sync)
+	if _, ok := r.lastDatas[1]; ok {
+		t.Fatal("Packet should not have been released by another sync address!")
+	}
+	sync.SetSyncAddress(7)
+	r.handleSync(sync)
+	if _, ok := r.lastDatas[1]; !ok {
+		t.Fatal("Packet should have been released by the sync packet!")
+	}
+	select {
+	case <-changed:
+	case <-time.After(time.Second):
+		t.Error("Callback was not called for the released packet!")
+	}
+}
+
+func TestHandleSyncTimeout(t *testing.T) {
+	r := newTestReceiver()
+	r.SetTimeout(10 * time.Millisecond)
+	p := NewDataPacket()
+	p.SetUniverse(1)
+	p.SetSyncAddress(7)
+	r.handle(p)
+	time.Sleep(20 * time.Millisecond)
+	r.checkForTimeouts()
+	if _, ok := r.lastDatas[1]; !ok {
+		t.Fatal("Held packet should have been released after the timeout!")
+	}
+
+	//the synchronization is lost, so packets with force_synchronization are not held anymore
+	p = p.copy()
+	p.SetUniverse(2)
+	p.SetForceSync(true)
+	r.handle(p)
+	if _, ok := r.lastDatas[2]; !ok {
+		t.Error("Packet with force_synchronization should not have been held!")
+	}
+	//but if the synchronization is active, they are held
+	sync := NewSyncPacket()
+	sync.SetSyncAddress(7)
+	r.handleSync(sync)
+	p = p.copy()
+	p.SetUniverse(3)
+	r.handle(p)
+	if _, ok := r.lastDatas[3]; ok {
+		t.Error("Packet with force_synchronization should have been held while synchronized!")
+	}
+}
+
+func TestHandleSyncSequence(t *testing.T) {
+	r := newTestReceiver()
+	p := NewDataPacket()
+	p.SetUniverse(1)
+	p.SetSyncAddress(7)
+	newSync := func(cid byte, sequence byte) SyncPacket {
+		sync := NewSyncPacket()
+		sync.SetCID([16]byte{cid})
+		sync.SetSyncAddress(7)
+		sync.SetSequence(sequence)
+		return sync
+	}
+	r.handleSync(newSync(0, 100))
+
+	//an old sync packet of the same source does not release the held data
+	r.handle(p)
+	r.handleSync(newSync(0, 95))
+	if _, ok := r.lastDatas[1]; ok {
+		t.Fatal("Out of order sync packet should have been ignored!")
+	}
+	r.handleSync(newSync(0, 101))
+	if _, ok := r.lastDatas[1]; !ok {
+		t.Fatal("Sync packet in order should have released the held data!")
+	}
+
+	//other sources have their own sequence numbers
+	p = p.copy()
+	p.SetUniverse(2)
+	r.handle(p)
+	r.handleSync(newSync(1, 3))
+	if _, ok := r.lastDatas[2]; !ok {
+		t.Error("Sync packet of another source should have released the held data!")
+	}
+}
+
+func TestHandleSyncLost(t *testing.T) {
+	r := newTestReceiver()
+	r.SetTimeout(10 * time.Millisecond)
+	errs := make(chan ReceiveError, 1)
+	r.OnError(func(err ReceiveError) {
+		errs <- err
+	})
+	p := NewDataPacket()
+	p.SetUniverse(1)
+	p.SetSyncAddress(7)
+	r.handle(p)
+	time.Sleep(20 * time.Millisecond)
+	r.checkForTimeouts()
+	select {
+	case err := <-errs:
+		if err.Err != ErrSyncLost || err.Universe != 7 {
+			t.Errorf("Expected ErrSyncLost for sync address 7, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Lost synchronization was not reported!")
+	}
+}
+
+func TestReceiverClock(t *testing.T) {
+	r, clock := newTestReceiverClock()
+	timeouts := make(chan uint16, 1)
+	r.SetTimeoutCallback(func(univ uint16) {
+		timeouts <- univ
+	})
+	p := NewDataPacket()
+	p.SetUniverse(1)
+	r.handle(p)
+
+	clock.Advance(r.Timeout())
+	r.checkForTimeouts()
+	if len(r.Sources(1)) != 1 {
+		t.Fatal("Source should not have timed out exactly at the timeout!")
+	}
+	clock.Advance(time.Millisecond)
+	r.checkForTimeouts()
+	if len(r.Sources(1)) != 0 {
+		t.Error("Source should have timed out after the timeout!")
+	}
+	select {
+	case univ := <-timeouts:
+		if univ != 1 {
+			t.Errorf("Timeout was called for universe %v, expected 1", univ)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timeout callback was not called!")
+	}
+}
+
+//winnerCID returns the CID of the source whose data is used for the universe
+func winnerCID(r *ReceiverSocket, universe uint16) [16]byte {
+	last := r.lastDatas[universe]
+	return last.lastPacket.CID()
+}
+
+func TestPriorityFailover(t *testing.T) {
+	r, clock := newTestReceiverClock()
+	if r.PriorityTimeout() != r.Timeout() {
+		t.Errorf("Default priority timeout should be the timeout, was %v", r.PriorityTimeout())
+	}
+	r.SetPriorityTimeout(500 * time.Millisecond)
+	if r.PriorityTimeout() != 500*time.Millisecond {
+		t.Fatalf("Priority timeout was not set! Was %v", r.PriorityTimeout())
+	}
+	primary := NewDataPacket()
+	primary.SetUniverse(1)
+	primary.SetCID([16]byte{1})
+	primary.SetPriority(MaxPriority)
+	primary.SetData([]byte{200})
+	backup := NewDataPacket()
+	backup.SetUniverse(1)
+	backup.SetCID([16]byte{2})
+	backup.SetPriority(DefaultPriority)
+	backup.SetData([]byte{100})
+	sendBackup := func() {
+		backup = backup.copy()
+		backup.SetSequence(backup.Sequence() + 1)
+		r.handle(backup)
+	}
+
+	r.handle(primary)
+	//the primary stops, the backup is ignored until the priority timeout
+	clock.Advance(500 * time.Millisecond)
+	sendBackup()
+	if cid := winnerCID(r, 1); cid != primary.CID() {
+		t.Fatal("Backup should not have taken over before the priority timeout!")
+	}
+	clock.Advance(time.Millisecond)
+	sendBackup()
+	if cid := winnerCID(r, 1); cid != backup.CID() {
+		t.Fatal("Backup should have taken over after the priority timeout!")
+	}
+	//the universe has not timed out, the backup keeps it alive
+	clock.Advance(time.Second)
+	sendBackup()
+	r.checkForTimeouts()
+	if cid := winnerCID(r, 1); cid != backup.CID() {
+		t.Fatal("Backup should still be used!")
+	}
+	//the primary returns and takes over immediately
+	primary = primary.copy()
+	primary.SetSequence(primary.Sequence() + 1)
+	r.handle(primary)
+	if cid := winnerCID(r, 1); cid != primary.CID() {
+		t.Error("Primary should have taken over again!")
+	}
+}
+
+func TestPriorityFailoverDefault(t *testing.T) {
+	r, clock := newTestReceiverClock()
+	primary := NewDataPacket()
+	primary.SetUniverse(1)
+	primary.SetCID([16]byte{1})
+	primary.SetPriority(MaxPriority)
+	backup := NewDataPacket()
+	backup.SetUniverse(1)
+	backup.SetCID([16]byte{2})
+	backup.SetPriority(DefaultPriority)
+
+	r.handle(primary)
+	clock.Advance(r.Timeout())
+	r.handle(backup)
+	if cid := winnerCID(r, 1); cid != primary.CID() {
+		t.Fatal("Backup should not have taken over before the timeout!")
+	}
+	clock.Advance(time.Millisecond)
+	backup.SetSequence(1)
+	r.handle(backup)
+	if cid := winnerCID(r, 1); cid != backup.CID() {
+		t.Error("Backup should have taken over after the timeout!")
+	}
+}
+
+func TestDropSource(t *testing.T) {
+	r := newTestReceiver()
+	primary := NewDataPacket()
+	primary.SetUniverse(1)
+	primary.SetCID([16]byte{1})
+	primary.SetPriority(MaxPriority)
+	backup := NewDataPacket()
+	backup.SetUniverse(1)
+	backup.SetCID([16]byte{2})
+	r.handle(primary)
+	r.handle(backup)
+	if len(r.Sources(1)) != 2 {
+		t.Fatalf("Expected 2 sources, got %v", len(r.Sources(1)))
+	}
+
+	r.DropSource(1, primary.CID())
+	if sources := r.Sources(1); len(sources) != 1 || sources[0].CID != backup.CID() {
+		t.Fatalf("Only the backup should be left, got %v", sources)
+	}
+	if cid := winnerCID(r, 1); cid != primary.CID() {
+		t.Error("The data of the dropped source should be kept until another source takes over!")
+	}
+	backup.SetSequence(1)
+	r.handle(backup)
+	if cid := winnerCID(r, 1); cid != backup.CID() {
+		t.Error("Backup should have taken over after the primary was dropped!")
+	}
+	//dropping an unknown source does nothing
+	r.DropSource(2, primary.CID())
+	if cid := winnerCID(r, 1); cid != backup.CID() {
+		t.Error("Dropping a source of another universe should not change the winner!")
+	}
+}
+
+func TestSourceTiming(t *testing.T) {
+	r, clock := newTestReceiverClock()
+	p := NewDataPacket()
+	p.SetUniverse(1)
+	//intervals of 20ms, 30ms and 25ms; the per-address priorities in between are not counted
+	for i, interval := range []time.Duration{0, 20, 30, 25} {
+		clock.Advance(interval * time.Millisecond)
+		p = p.copy()
+		p.SetSequence(byte(2 * i))
+		p.SetDmxStartCode(StartCodeDimmer)
+		r.handle(p)
+		p = p.copy()
+		p.SetSequence(byte(2*i + 1))
+		p.SetDmxStartCode(StartCodePerAddressPriority)
+		r.handle(p)
+	}
+	timing := r.Sources(1)[0].Timing
+	if timing.Intervals != 3 || timing.Min != 20*time.Millisecond || timing.Max != 30*time.Millisecond ||
+		timing.Mean != 25*time.Millisecond {
+		t.Errorf("Wrong timing! Was: %+v", timing)
+	}
+	//the deviations are 10ms and 5ms
+	if jitter := (10*time.Millisecond)/16 + (5*time.Millisecond-(10*time.Millisecond)/16)/16; timing.Jitter != jitter {
+		t.Errorf("Wrong jitter! Was: %v; Should've been: %v", timing.Jitter, jitter)
+	}
+}
+
+func TestHandleConcurrent(t *testing.T) {
+	r := newTestReceiver()
+	r.socket = &fakeConn{}
+	r.multicastInterface = &net.Interface{Index: 1, Name: "fake"}
+	r.SetTimeout(time.Millisecond)
+	done := make(chan struct{})
+	//join and leave the universes while their packets stream in
+	go func() {
+		for j := 0; j < 50; j++ {
+			for univ := uint16(1); univ <= 4; univ++ {
+				r.JoinUniverse(univ)
+				r.LastData(univ)
+				r.LeaveUniverse(univ)
+			}
+			r.SetActiveUniverses(map[uint16]struct{}{uint16(j%4 + 1): {}})
+		}
+		done <- struct{}{}
+	}()
+	for i := 0; i < 4; i++ {
+		go func(univ uint16) {
+			p := NewDataPacket()
+			p.SetUniverse(univ)
+			for j := 0; j < 200; j++ {
+				p = p.copy()
+				p.SequenceIncr()
+				r.handle(p)
+			}
+			done <- struct{}{}
+		}(uint16(i + 1))
+	}
+	//change the callbacks and read the state while the packets are handled
+	for i := 0; i < 50; i++ {
+		r.SetOnChangeCallback(func(old, new DataPacket) {})
+		r.SetTimeoutCallback(func(univ uint16) {})
+		r.JoinedUniverses()
+	}
+	for i := 0; i < 5; i++ {
+		<-done
+	}
+}
+
+func TestHandleRemovedUniverse(t *testing.T) {
+	r := newTestReceiver()
+	r.socket = &fakeConn{}
+	r.multicastInterface = &net.Interface{Index: 1, Name: "fake"}
+	r.SetTimeout(time.Millisecond)
+	done := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		go func(univ uint16) {
+			p := NewDataPacket()
+			p.SetUniverse(univ)
+			for j := 0; j < 200; j++ {
+				p = p.copy()
+				p.SequenceIncr()
+				r.handle(p)
+			}
+			done <- struct{}{}
+		}(uint16(i + 1))
+	}
+	//remove the data of the universes while their packets are handled
+	sets := []map[uint16]struct{}{{1: {}, 2: {}}, {3: {}, 4: {}}}
+	for i := 0; i < 50; i++ {
+		r.SetActiveUniverses(sets[i%2])
+		r.DropSource(uint16(i%4+1), [16]byte{})
+		r.stateMu.Lock()
+		r.checkForTimeouts()
+		r.stateMu.Unlock()
+	}
+	for i := 0; i < 4; i++ {
+		<-done
+	}
+}
+
+func TestStoreLastPacketTime(t *testing.T) {
+	r := newTestReceiver()
+	p := NewDataPacket()
+	p.SetUniverse(1)
+	p.SetSequence(100)
+	r.handle(p)
+	first := r.lastDatas[1].lastTime
+	time.Sleep(5 * time.Millisecond)
+
+	p = p.copy()
+	p.SequenceIncr()
+	r.handle(p)
+	second := r.lastDatas[1].lastTime
+	if !second.After(first) {
+		t.Errorf("Last time did not advance! Was: %v, then: %v", first, second)
+	}
+
+	//an out of order packet should not update the time
+	time.Sleep(5 * time.Millisecond)
+	p = p.copy()
+	p.SetSequence(p.Sequence() - 5)
+	r.handle(p)
+	if !r.lastDatas[1].lastTime.Equal(second) {
+		t.Error("Last time was updated by an out of order packet!")
+	}
+}
+
+func TestDeliveryQueue(t *testing.T) {
+	for _, policy := range []DropPolicy{DropNewest, DropOldest} {
+		r := newTestReceiver()
+		block := make(chan struct{})
+		received := make(chan byte, 10)
+		r.SetOnChangeCallback(func(old, new DataPacket) {
+			<-block
+			received <- new.Data()[0]
+		})
+		r.SetDeliveryQueue(2, policy)
+		//the first change is taken by the callback, the next two fill the queue, the rest is dropped
+		for i := 0; i < 5; i++ {
+			p := NewDataPacket()
+			p.SetUniverse(1)
+			p.SetSequence(byte(i))
+			p.SetData([]byte{byte(i + 1)})
+			r.handle(p)
+			time.Sleep(10 * time.Millisecond)
+		}
+		if r.Dropped() != 2 {
+			t.Errorf("Wrong count of dropped changes! Was: %v", r.Dropped())
+		}
+		close(block)
+		got := []byte{<-received, <-received, <-received}
+		want := []byte{1, 2, 3}
+		if policy == DropOldest {
+			want = []byte{1, 4, 5}
+		}
+		if got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+			t.Errorf("Wrong changes were delivered with policy %v! Was: %v", policy, got)
+		}
+		r.stateMu.Lock()
+		r.stopDelivery()
+		r.stateMu.Unlock()
+	}
+}
+
+func TestCloseAndDrain(t *testing.T) {
+	r := newTestReceiver()
+	r.socket = &fakeConn{}
+	var mu sync.Mutex
+	var got []byte
+	r.SetOnChangeCallback(func(old, new DataPacket) {
+		time.Sleep(5 * time.Millisecond) //slower than the receiver, so that the changes are queued
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, new.Data()[0])
+	})
+	r.SetDeliveryQueue(10, DropNewest)
+	r.Start()
+	for i := 0; i < 5; i++ {
+		p := NewDataPacket()
+		p.SetUniverse(1)
+		p.SetSequence(byte(i))
+		p.SetData([]byte{byte(i + 1)})
+		r.handle(p)
+	}
+	if err := r.CloseAndDrain(); err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(got, []byte{1, 2, 3, 4, 5}) {
+		t.Errorf("Not all queued changes were delivered before CloseAndDrain returned: %v", got)
+	}
+	//without a queue there is nothing to wait for
+	r = newTestReceiver()
+	r.socket = &fakeConn{}
+	if err := r.CloseAndDrain(); err != nil {
+		t.Error(err)
+	}
+}
+
+//BenchmarkHandle measures one second of traffic with 100 universes at 44 packets per second
+func BenchmarkHandle(b *testing.B) {
+	r := newTestReceiver()
+	r.SetOnChangeCallback(func(old, new DataPacket) {})
+	packets := make([]DataPacket, 100)
+	for i := range packets {
+		packets[i] = NewDataPacket()
+		packets[i].SetUniverse(uint16(i + 1))
+		packets[i].SetData(make([]byte, 512))
+	}
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for frame := 0; frame < 44; frame++ {
+			for i := range packets {
+				packets[i].SequenceIncr()
+				packets[i].data[126] = byte(frame)
+				r.handle(packets[i])
+			}
+		}
+	}
+}
+
+func TestHandleSequenceOrder(t *testing.T) {
+	r := newTestReceiver()
+	received := make(chan DataPacket, 300)
+	r.SetOnChangeCallback(func(old, new DataPacket) {
+		received <- new
+	})
+	r.SetDeliveryQueue(300, DropNewest)
+	p := NewDataPacket()
+	p.SetUniverse(1)
+	for i := 0; i < 300; i++ {
+		p = p.copy()
+		p.SequenceIncr()
+		p.SetData([]byte{byte(i), byte(i >> 8)})
+		r.handle(p)
+	}
+	for i := 0; i < 300; i++ {
+		select {
+		case got := <-received:
+			if value := int(got.Data()[0]) | int(got.Data()[1])<<8; value != i {
+				t.Fatalf("Packet %v was delivered at position %v!", value, i)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Only %v of 300 packets were delivered!", i)
+		}
+	}
+	if r.Dropped() != 0 {
+		t.Errorf("No packet should have been dropped, but %v were", r.Dropped())
+	}
+}
+
+func TestSourceChangeCallback(t *testing.T) {
+	r := newTestReceiver()
+	type sourceChange struct {
+		oldCID, newCID [16]byte
+		priority       byte
+	}
+	changes := make(chan sourceChange, 10)
+	r.SetSourceChangeCallback(func(univ uint16, oldCID, newCID [16]byte, priority byte) {
+		changes <- sourceChange{oldCID, newCID, priority}
+	})
+	primary := NewDataPacket()
+	primary.SetUniverse(1)
+	primary.SetCID([16]byte{1})
+	r.handle(primary)
+	for i := 0; i < 3; i++ { //the same source should not invoke the callback again
+		primary = primary.copy()
+		primary.SequenceIncr()
+		r.handle(primary)
+	}
+	backup := NewDataPacket()
+	backup.SetUniverse(1)
+	backup.SetCID([16]byte{2})
+	backup.SetPriority(150)
+	r.handle(backup)
+	//the primary source takes over again with a higher priority directly after the backup
+	primary = primary.copy()
+	primary.SequenceIncr()
+	primary.SetPriority(200)
+	r.handle(primary)
+
+	want := []sourceChange{
+		{[16]byte{}, [16]byte{1}, 100},
+		{[16]byte{1}, [16]byte{2}, 150},
+		{[16]byte{2}, [16]byte{1}, 200},
+	}
+	for i := range want {
+		select {
+		case got := <-changes:
+			if got != want[i] {
+				t.Errorf("Source change %v: got %v, want %v", i, got, want[i])
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Source change callback was not called!")
+		}
+	}
+	select {
+	case got := <-changes:
+		t.Errorf("Source change callback was called too often: %v", got)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestLastData(t *testing.T) {
+	r := newTestReceiver()
+	if _, ok := r.LastData(1); ok {
+		t.Error("There should be no data without packets!")
+	}
+	p := NewDataPacket()
+	p.SetUniverse(1)
+	p.SetData([]byte{1, 2, 3, 4})
+	r.handle(p)
+	data, ok := r.LastData(1)
+	if !ok || !bytes.Equal(data, []byte{1, 2, 3, 4}) {
+		t.Errorf("Wrong last data! Was: %v", data)
+	}
+	data[0] = 100
+	if again, _ := r.LastData(1); again[0] != 1 {
+		t.Error("LastData should have returned a copy!")
+	}
+}
+
+func TestArbitrationPolicy(t *testing.T) {
+	tests := []struct {
+		policy ArbitrationPolicy
+		want   []byte //the data of the universe after both sources have sent
+	}{
+		{LastReceived, []byte{1}},
+		{FirstReceived, []byte{2}},
+		{HighestCID, []byte{2}},
+		{ErrorOnConflict, []byte{2}},
+	}
+	for _, tt := range tests {
+		r := newTestReceiver()
+		r.SetArbitrationPolicy(tt.policy)
+		high := NewDataPacket()
+		high.SetUniverse(1)
+		high.SetCID([16]byte{2})
+		high.SetData([]byte{2})
+		low := NewDataPacket()
+		low.SetUniverse(1)
+		low.SetCID([16]byte{1})
+		low.SetData([]byte{1})
+		r.handle(high)
+		r.handle(low)
+		if data, _ := r.LastData(1); !bytes.Equal(data[:1], tt.want) {
+			t.Errorf("Policy %v: wrong data! Was: %v; Should've been: %v", tt.policy, data[:1], tt.want)
+		}
+	}
+}
+
+func TestErrorOnConflict(t *testing.T) {
+	r := newTestReceiver() //ErrorOnConflict is the default policy
+	errs := make(chan ReceiveError, 10)
+	r.OnError(func(err ReceiveError) {
+		errs <- err
+	})
+	used := NewDataPacket()
+	used.SetUniverse(1)
+	used.SetCID([16]byte{1})
+	used.SetData([]byte{1})
+	r.handle(used)
+	for i := 0; i < 3; i++ {
+		other := NewDataPacket()
+		other.SetUniverse(1)
+		other.SetCID([16]byte{2})
+		other.SetSequence(byte(i))
+		other.SetData([]byte{2})
+		r.handle(other)
+	}
+	select {
+	case err := <-errs:
+		if err.Err != ErrSourcesExceeded || err.Universe != 1 ||
+			!reflect.DeepEqual(err.CIDs, [][16]byte{{1}, {2}}) {
+			t.Errorf("Wrong error! Was: %+v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("The conflict was not reported!")
+	}
+	time.Sleep(10 * time.Millisecond)
+	if len(errs) != 0 {
+		t.Error("The conflicting source should only be reported once!")
+	}
+	if data, _ := r.LastData(1); data[0] != 1 {
+		t.Errorf("The data of the conflicting source was used! Data: %v", data[0])
+	}
+}
+
+func TestHandlePriorityChange(t *testing.T) {
+	r := newTestReceiver()
+	changes := make(chan DataPacket, 10)
+	r.SetOnChangeCallback(func(old DataPacket, new DataPacket) {
+		changes <- new
+	})
+	p := NewDataPacket()
+	p.SetUniverse(1)
+	p.SetCID([16]byte{1})
+	p.SetData([]byte{1, 2, 3})
+	r.handle(p)
+	<-changes
+	//the same data with another priority from another source has to be delivered
+	p = p.copy()
+	p.SetCID([16]byte{2})
+	p.SetPriority(150)
+	r.handle(p)
+	select {
+	case got := <-changes:
+		if got.Priority() != 150 {
+			t.Errorf("Wrong priority! Was: %v", got.Priority())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Priority change was not delivered!")
+	}
+	//the same data with the same priority must not be delivered again
+	p = p.copy()
+	p.SequenceIncr()
+	r.handle(p)
+	select {
+	case got := <-changes:
+		t.Errorf("Unchanged data was delivered: %v", got.Priority())
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestHandleStartCode(t *testing.T) {
+	r := newTestReceiver()
+	changes := make(chan DataPacket, 10)
+	r.SetOnChangeCallback(func(old DataPacket, new DataPacket) {
+		changes <- new
+	})
+	alternate := make(chan DataPacket, 10)
+	r.SetStartCodeCallback(func(p DataPacket) {
+		alternate <- p
+	})
+	tests := []struct {
+		startCode byte
+		isData    bool //true, if the packet should be delivered as DMX data
+		isAlt     bool //true, if the packet should be given to the start code callback
+	}{
+		{StartCodeDimmer, true, false},
+		{StartCodeRDM, false, true},
+		{StartCodePerAddressPriority, false, false},
+	}
+	for i, tt := range tests {
+		p := NewDataPacket()
+		p.SetUniverse(uint16(i + 1))
+		p.SetDmxStartCode(tt.startCode)
+		p.SetData([]byte{1, 2, 3})
+		r.handle(p)
+		for _, c := range []struct {
+			ch   chan DataPacket
+			want bool
+		}{{changes, tt.isData}, {alternate, tt.isAlt}} {
+			select {
+			case got := <-c.ch:
+				if !c.want {
+					t.Errorf("Start code %X: unexpected packet %v", tt.startCode, got.Universe())
+				} else if got.DmxStartCode() != tt.startCode {
+					t.Errorf("Wrong start code! Was: %X; Should've been: %X", got.DmxStartCode(), tt.startCode)
+				}
+			case <-time.After(50 * time.Millisecond):
+				if c.want {
+					t.Errorf("Start code %X: packet was not delivered", tt.startCode)
+				}
+			}
+		}
+	}
+}
+
+func TestSetSequenceCheck(t *testing.T) {
+	for _, enabled := range []bool{true, false} {
+		r := newTestReceiver()
+		r.SetSequenceCheck(enabled)
+		p := NewDataPacket()
+		p.SetUniverse(1)
+		p.SetSequence(50)
+		p.SetData([]byte{0})
+		r.handle(p)
+		p = p.copy()
+		p.SetSequence(49) //out of order
+		p.SetData([]byte{1})
+		r.handle(p)
+		if data, _ := r.LastData(1); (data[0] == 1) == enabled {
+			t.Errorf("Sequence check %v: wrong data %v", enabled, data[0])
+		}
+	}
+}
+
+func TestSetSequenceCheckRepeated(t *testing.T) {
+	//a test source that sends every frame with the same sequence number
+	r := newTestReceiver()
+	r.SetSequenceCheck(false)
+	for i := 0; i < 5; i++ {
+		p := NewDataPacket()
+		p.SetUniverse(1)
+		p.SetSequence(7)
+		p.SetData([]byte{byte(i)})
+		r.handle(p)
+		if data, _ := r.LastData(1); data[0] != byte(i) {
+			t.Errorf("Frame %v with a repeated sequence number was not delivered, data: %v", i, data[0])
+		}
+	}
+	if suppressed := r.Stats().Suppressed; suppressed != 0 {
+		t.Errorf("No frame should have been suppressed as duplicate! Was: %v", suppressed)
+	}
+}
+
+func TestSources(t *testing.T) {
+	r := newTestReceiver()
+	if sources := r.Sources(1); len(sources) != 0 {
+		t.Errorf("There should be no sources yet! Was: %v", sources)
+	}
+	backup := NewDataPacket()
+	backup.SetUniverse(1)
+	backup.SetCID([16]byte{2})
+	backup.SetSourceName("backup")
+	backup.SetPriority(50)
+	r.handle(backup)
+	primary := NewDataPacket()
+	primary.SetUniverse(1)
+	primary.SetCID([16]byte{1})
+	primary.SetSourceName("primary")
+	r.handle(primary)
+
+	sources := r.Sources(1)
+	if len(sources) != 2 || sources[0].SourceName != "primary" || sources[0].Priority != 100 ||
+		sources[1].CID != [16]byte{2} || sources[1].Priority != 50 || sources[1].LastSeen.IsZero() {
+		t.Fatalf("Wrong sources! Was: %v", sources)
+	}
+	//a terminated source has to be removed
+	backup = backup.copy()
+	backup.SetStreamTerminated(true)
+	r.handle(backup)
+	if sources := r.Sources(1); len(sources) != 1 || sources[0].CID != [16]byte{1} {
+		t.Errorf("Terminated source was not removed! Was: %v", sources)
+	}
+	//a timed out source has to be removed
+	r.SetTimeout(time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	r.stateMu.Lock()
+	r.checkForTimeouts()
+	r.stateMu.Unlock()
+	if sources := r.Sources(1); len(sources) != 0 {
+		t.Errorf("Timed out source was not removed! Was: %v", sources)
+	}
+}
+
+func TestReceiverTimeoutRecovered(t *testing.T) {
+	r := newTestReceiver()
+	r.SetTimeout(10 * time.Millisecond)
+	events := make(chan string, 10)
+	r.SetTimeoutCallback(func(univ uint16) {
+		events <- "timeout"
+	})
+	r.SetRecoveredCallback(func(univ uint16) {
+		events <- "recovered"
+	})
+	p := NewDataPacket()
+	p.SetUniverse(5)
+	r.handle(p)
+	time.Sleep(20 * time.Millisecond)
+	for i := 0; i < 3; i++ { //the timeout must only be reported once
+		r.stateMu.Lock()
+		r.checkForTimeouts()
+		r.stateMu.Unlock()
+	}
+	expect := func(want string) {
+		select {
+		case got := <-events:
+			if got != want {
+				t.Errorf("Wrong event! Was: %v; Should've been: %v", got, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Event %v was not reported!", want)
+		}
+	}
+	expect("timeout")
+	p = p.copy()
+	p.SequenceIncr()
+	r.handle(p)
+	expect("recovered")
+	select {
+	case got := <-events:
+		t.Errorf("Unexpected event: %v", got)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestOnDataOnError(t *testing.T) {
+	r := newTestReceiver()
+	data := make(chan DataPacket, 10)
+	r.OnData(2, func(p DataPacket) {
+		data <- p
+	})
+	errs := make(chan ReceiveError, 10)
+	r.OnError(func(err ReceiveError) {
+		errs <- err
+	})
+	for _, univ := range []uint16{1, 2} {
+		p := NewDataPacket()
+		p.SetUniverse(univ)
+		p.SetCID([16]byte{byte(univ)})
+		r.handle(p)
+	}
+	select {
+	case p := <-data:
+		if p.Universe() != 2 {
+			t.Errorf("Data handler was called for the wrong universe: %v", p.Universe())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Data handler was not called!")
+	}
+
+	p := NewDataPacket()
+	p.SetUniverse(2)
+	p.SetCID([16]byte{2})
+	p.SetStreamTerminated(true)
+	r.handle(p)
+	select {
+	case err := <-errs:
+		if err.Err != ErrStreamTerminated || err.Universe != 2 || len(err.CIDs) != 1 || err.CIDs[0] != p.CID() {
+			t.Errorf("Wrong error! Was: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Error handler was not called for the terminated stream!")
+	}
+
+	r.SetTimeout(time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	r.stateMu.Lock()
+	r.checkForTimeouts()
+	r.stateMu.Unlock()
+	select {
+	case err := <-errs:
+		if err.Err != ErrTimeout || err.Universe != 1 {
+			t.Errorf("Wrong error! Was: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Error handler was not called for the timeout!")
+	}
+	select {
+	case p := <-data:
+		t.Errorf("Unexpected data on universe %v", p.Universe())
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestStats(t *testing.T) {
+	p := NewDataPacket()
+	p.SetUniverse(1)
+	p.SetCID([16]byte{1})
+	p.SetSequence(10)
+	p.SetData([]byte{1})
+	unchanged := p.copy()
+	unchanged.SetSequence(11)
+	conflict := unchanged.copy()
+	conflict.SetCID([16]byte{2})
+	conflict.SetSequence(1)
+	conn := &fakeConn{reads: []fakeRead{
+		{data: []byte("no sACN packet")}, {data: p.Bytes()}, {data: unchanged.Bytes()}, {data: conflict.Bytes()},
+	}}
+	r := newTestReceiver()
+	r.socket = conn
+	r.Start()
+	defer r.Close()
+	var stats Stats
+	for start := time.Now(); time.Since(start) < time.Second; time.Sleep(time.Millisecond) {
+		if stats = r.Stats(); stats.PacketsReceived == 4 && stats.Suppressed == 2 {
+			break
+		}
+	}
+	want := Stats{PacketsReceived: 4, ParseErrors: 1, Delivered: 1, Suppressed: 2, Conflicts: 1}
+	//with the default ErrorOnConflict policy the first source keeps the universe
+	if seq := stats.Sequences[1]; seq != 11 || len(stats.Sequences) != 1 {
+		t.Errorf("Wrong sequences! Was: %v", stats.Sequences)
+	}
+	stats.Sequences = nil
+	if !reflect.DeepEqual(stats, want) {
+		t.Errorf("Wrong stats!\nWas:            %+v\nShould've been: %+v", stats, want)
+	}
+}
+
+func TestJoinUniverseRange(t *testing.T) {
+	r := newTestReceiver()
+	conn := &fakeConn{}
+	r.socket = conn
+	if err := r.JoinUniverseRange(5, 4); err == nil {
+		t.Error("A range with first > last should fail!")
+	}
+	r.multicastInterface = &net.Interface{Index: 1, Name: "fake"}
+	if err := r.JoinUniverseRange(1, 64); err != nil {
+		t.Fatal(err)
+	}
+	if joined := r.JoinedUniverses(); len(joined) != 64 || joined[0] != 1 || joined[63] != 64 {
+		t.Errorf("Wrong universes were joined: %v", joined)
+	}
+	if err := r.LeaveUniverseRange(2, 64); err != nil {
+		t.Fatal(err)
+	}
+	if joined := r.JoinedUniverses(); len(joined) != 1 || joined[0] != 1 {
+		t.Errorf("Wrong universes are still joined: %v", joined)
+	}
+}
+
+func TestSetActiveUniverses(t *testing.T) {
+	r := newTestReceiver()
+	conn := &fakeConn{}
+	r.socket = conn
+	r.multicastInterface = &net.Interface{Index: 1, Name: "fake"}
+	if err := r.SetActiveUniverses(map[uint16]struct{}{1: {}, 2: {}, 3: {}}); err != nil {
+		t.Fatal(err)
+	}
+	for _, universe := range []uint16{2, 3} {
+		p := NewDataPacket()
+		p.SetUniverse(universe)
+		r.handle(p)
+	}
+	r.OnData(3, func(p DataPacket) {})
+
+	if err := r.SetActiveUniverses(map[uint16]struct{}{1: {}, 4: {}}); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"join fake 1", "join fake 2", "join fake 3", "leave fake 2", "leave fake 3", "join fake 4"}
+	if !reflect.DeepEqual(conn.events, want) {
+		t.Errorf("Wrong events!\nWas:            %v\nShould've been: %v", conn.events, want)
+	}
+	if joined := r.JoinedUniverses(); !reflect.DeepEqual(joined, []uint16{1, 4}) {
+		t.Errorf("Wrong universes are joined: %v", joined)
+	}
+	if _, ok := r.lastDatas[2]; ok {
+		t.Error("The data of a left universe should have been removed!")
+	}
+	if _, ok := r.lastDatas[3]; !ok {
+		t.Error("The data of a left universe that is used by OnData should have been kept!")
+	}
+
+	if err := r.SetActiveUniverses(map[uint16]struct{}{1: {}, 0: {}}); err == nil {
+		t.Error("An invalid universe should fail!")
+	}
+	if joined := r.JoinedUniverses(); !reflect.DeepEqual(joined, []uint16{1, 4}) {
+		t.Errorf("An invalid set should not change the joined universes: %v", joined)
+	}
+	r.multicastInterface = nil
+	err := r.SetActiveUniverses(map[uint16]struct{}{5: {}})
+	if rangeErr, ok := err.(*RangeError); !ok || rangeErr.Failed[5] != ErrNoMulticastInterface {
+		t.Errorf("Joining without an interface should fail! Was: %v", err)
+	}
+}
+
+func TestSetMulticastInterface(t *testing.T) {
+	r := newTestReceiver()
+	conn := &fakeConn{}
+	r.socket = conn
+	if err := r.SetMulticastInterface(&net.Interface{Index: 1, Name: "eth0"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.JoinUniverse(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.SetMulticastInterface(&net.Interface{Index: 2, Name: "wlan0"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.SetMulticastInterface(nil); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"join eth0 1", "leave eth0 1", "join wlan0 1", "leave wlan0 1"}
+	if !reflect.DeepEqual(conn.events, want) {
+		t.Errorf("Wrong events!\nWas:            %v\nShould've been: %v", conn.events, want)
+	}
+	if joined := r.JoinedUniverses(); len(joined) != 0 {
+		t.Errorf("No universe should be joined without an interface: %v", joined)
+	}
+	if err := r.JoinUniverse(1); err != ErrNoMulticastInterface {
+		t.Errorf("Joining without an interface should fail! Was: %v", err)
+	}
+}
+
+func TestHandleFirstFrame(t *testing.T) {
+	r := newTestReceiver()
+	r.SetTimeout(10 * time.Millisecond)
+	changes := make(chan DataPacket, 10)
+	r.SetOnChangeCallback(func(old DataPacket, new DataPacket) {
+		changes <- new
+	})
+	p := NewDataPacket()
+	p.SetUniverse(1)
+	p.SetData(make([]byte, 512)) //all zero
+	for i := 0; i < 2; i++ {
+		//the first frame and the first frame after a timeout have to be delivered, even if they are dark
+		r.handle(p)
+		select {
+		case got := <-changes:
+			if got.DataLength() != 512 {
+				t.Errorf("Wrong data length! Was: %v", got.DataLength())
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Frame %v was not delivered!", i)
+		}
+		time.Sleep(20 * time.Millisecond)
+		p = p.copy()
+		p.SequenceIncr()
+	}
+}
+
+func TestIsReceiving(t *testing.T) {
+	r := newTestReceiver()
+	r.SetTimeout(10 * time.Millisecond)
+	if r.IsReceiving(1) {
+		t.Error("Universe without data should not be receiving!")
+	}
+	p := NewDataPacket()
+	p.SetUniverse(1)
+	r.handle(p)
+	if !r.IsReceiving(1) || r.IsReceiving(2) {
+		t.Errorf("Only universe 1 should be receiving! Was: %v, %v", r.IsReceiving(1), r.IsReceiving(2))
+	}
+	time.Sleep(20 * time.Millisecond)
+	if r.IsReceiving(1) {
+		t.Error("Timed out universe should not be receiving!")
+	}
+}
+
+func TestWaitForData(t *testing.T) {
+	r := newTestReceiver()
+	p := NewDataPacket()
+	p.SetUniverse(1)
+	p.SetData([]byte{1})
+	r.handle(p)
+
+	result := make(chan DataPacket, 1)
+	go func() {
+		received, err := r.WaitForData(context.Background(), 1)
+		if err != nil {
+			t.Error(err)
+		}
+		result <- received
+	}()
+	//wait until the call is waiting, then send a frame with the same data
+	for waiting := false; !waiting; {
+		time.Sleep(time.Millisecond)
+		r.stateMu.Lock()
+		waiting = len(r.waiters[1]) == 1
+		r.stateMu.Unlock()
+	}
+	p.SequenceIncr()
+	r.handle(p)
+	select {
+	case received := <-result:
+		if received.Sequence() != p.Sequence() || !bytes.Equal(received.Data(), p.Data()) {
+			t.Errorf("Wrong frame was returned! Was: %v", received)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("WaitForData did not return the unchanged frame")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := r.WaitForData(ctx, 2)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Wrong error on timeout! Was: %v", err)
+	}
+	if len(r.waiters) != 0 {
+		t.Errorf("Waiters were not removed! Was: %v", r.waiters)
+	}
+}
+
+func TestSourceFilter(t *testing.T) {
+	newPacket := func(cid byte) DataPacket {
+		p := NewDataPacket()
+		p.SetUniverse(1)
+		p.SetCID([16]byte{cid})
+		p.SetPriority(100 + cid) //every source wins against the sources before
+		p.SetData([]byte{cid})
+		return p
+	}
+	current := func(r *ReceiverSocket) byte {
+		data, _ := r.LastData(1)
+		if len(data) == 0 {
+			return 0
+		}
+		return data[0]
+	}
+
+	r := newTestReceiver()
+	r.AllowSource([16]byte{1})
+	r.AllowSource([16]byte{3})
+	r.DenySource([16]byte{3})
+	for cid := byte(1); cid <= 3; cid++ {
+		r.handle(newPacket(cid))
+	}
+	if current(r) != 1 {
+		t.Errorf("Only source 1 should be accepted! Data of source %v was used", current(r))
+	}
+	if sources := r.Sources(1); len(sources) != 1 {
+		t.Errorf("Filtered sources should not be listed! Was: %v", sources)
+	}
+
+	//an empty allow list accepts all sources that are not denied
+	r = newTestReceiver()
+	r.DenySource([16]byte{2})
+	r.handle(newPacket(1))
+	r.handle(newPacket(2))
+	if current(r) != 1 {
+		t.Errorf("Source 2 should be denied! Data of source %v was used", current(r))
+	}
+	r.handle(newPacket(3))
+	if current(r) != 3 {
+		t.Errorf("Source 3 should be accepted! Data of source %v was used", current(r))
+	}
+}
+
+func TestSetUniverseMap(t *testing.T) {
+	r := newTestReceiver()
+	r.SetUniverseMap(func(in uint16) (uint16, bool) {
+		return in + 100, in != 2
+	})
+	delivered := make(chan DataPacket, 2)
+	r.OnData(101, func(p DataPacket) {
+		delivered <- p
+	})
+	for _, univ := range []uint16{1, 2} {
+		p := NewDataPacket()
+		p.SetUniverse(univ)
+		p.SetData([]byte{byte(univ)})
+		r.handle(p)
+	}
+	select {
+	case p := <-delivered:
+		if p.Universe() != 101 || p.Data()[0] != 1 {
+			t.Errorf("Wrong packet was delivered! Universe: %v, data: %v", p.Universe(), p.Data())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("The patched universe was not delivered!")
+	}
+	if _, ok := r.LastData(1); ok {
+		t.Error("The universe on the wire should not be used!")
+	}
+	if _, ok := r.LastData(102); ok {
+		t.Error("Universe 2 should have been dropped!")
+	}
+
+	r.SetUniverseMap(nil)
+	p := NewDataPacket()
+	p.SetUniverse(1)
+	p.SetSequence(1)
+	r.handle(p)
+	if _, ok := r.LastData(1); !ok {
+		t.Error("The mapping was not removed!")
+	}
+}
+
+func TestHandlePriorityOutOfRange(t *testing.T) {
+	r := newTestReceiver()
+	valid := NewDataPacket()
+	valid.SetUniverse(1)
+	valid.SetCID([16]byte{1})
+	valid.SetPriority(MaxPriority)
+	valid.SetData([]byte{1})
+	r.handle(valid)
+
+	//a source with priority 255 must not win against a source with the highest valid priority
+	rogue := NewDataPacket()
+	rogue.SetUniverse(1)
+	rogue.SetCID([16]byte{2})
+	rogue.SetData([]byte{2})
+	rogue.data[108] = 255
+	r.SetArbitrationPolicy(FirstReceived)
+	r.handle(rogue)
+	if data, _ := r.LastData(1); data[0] != 1 {
+		t.Errorf("The source with the invalid priority has won! Data: %v", data)
+	}
+	for _, src := range r.Sources(1) {
+		if src.Priority > MaxPriority {
+			t.Errorf("The priority was not clamped! Was: %v", src.Priority)
+		}
+	}
+}
+
+func TestHandleDuplicates(t *testing.T) {
+	r := newTestReceiver()
+	r.SetDeliveryQueue(10, DropNewest)
+	delivered := make(chan DataPacket, 10)
+	r.OnData(1, func(p DataPacket) {
+		delivered <- p
+	})
+	p := NewDataPacket()
+	p.SetUniverse(1)
+	p.SetData([]byte{1})
+	next := p.copy()
+	next.SetSequence(1)
+	next.SetData([]byte{2})
+	//every packet is received via unicast and multicast
+	for _, packet := range []DataPacket{p, p, next, p, next} {
+		r.handle(packet)
+	}
+	for _, want := range []byte{1, 2} {
+		select {
+		case got := <-delivered:
+			if got.Data()[0] != want {
+				t.Errorf("Wrong data! Was: %v; Should've been: %v", got.Data()[0], want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Data %v was not delivered!", want)
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	select {
+	case got := <-delivered:
+		t.Errorf("A duplicate was delivered! Data: %v", got.Data())
+	default:
+	}
+	if stats := r.Stats(); stats.Delivered != 2 || stats.Suppressed != 3 {
+		t.Errorf("Wrong stats! Was: %+v", stats)
+	}
+}
+
+func TestHandleDuplicatesClock(t *testing.T) {
+	r, clock := newTestReceiverClock()
+	p := NewDataPacket()
+	p.SetUniverse(1)
+	r.handle(p)
+	//the universe times out and the source restarts with the same sequence number, which is only
+	//no duplicate according to the clock of the receiver
+	clock.Advance(r.Timeout() + dedupMaxAge)
+	r.handle(p)
+	if stats := r.Stats(); stats.Delivered != 2 || stats.Suppressed != 0 {
+		t.Errorf("Wrong stats! Was: %+v", stats)
+	}
+}
+
+func TestDeliverUnchanged(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		r := newTestReceiver()
+		r.SetDeliverUnchanged(enabled)
+		r.SetDeliveryQueue(10, DropNewest)
+		delivered := make(chan DataPacket, 10)
+		r.OnData(1, func(p DataPacket) {
+			delivered <- p
+		})
+		p := NewDataPacket()
+		p.SetUniverse(1)
+		p.SetData([]byte{1})
+		for i := 0; i < 3; i++ {
+			p = p.copy()
+			p.SetSequence(byte(i))
+			r.handle(p)
+		}
+		//an out of order frame is never delivered
+		old := p.copy()
+		old.SetSequence(0)
+		r.handle(old)
+
+		want := 1
+		if enabled {
+			want = 3
+		}
+		for i := 0; i < want; i++ {
+			select {
+			case <-delivered:
+			case <-time.After(time.Second):
+				t.Fatalf("Only %v of %v frames were delivered with enabled %v!", i, want, enabled)
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		if len(delivered) != 0 {
+			t.Errorf("Too many frames were delivered with enabled %v!", enabled)
+		}
+	}
+}
+
+func TestSetInterest(t *testing.T) {
+	r := newTestReceiver()
+	if err := r.SetInterest(1, 1, 24); err != nil {
+		t.Fatal(err)
+	}
+	r.SetDeliveryQueue(10, DropNewest)
+	delivered := make(chan DataPacket, 10)
+	r.OnData(1, func(p DataPacket) {
+		delivered <- p
+	})
+	p := NewDataPacket()
+	p.SetUniverse(1)
+	data := make([]byte, 512)
+	for i, channel := range []int{1, 100, 24, 512, 25} {
+		data[channel-1]++
+		p = p.copy()
+		p.SetSequence(byte(i))
+		p.SetData(data)
+		r.handle(p)
+	}
+	//the first frame and the change of channel 24 are delivered, the others are out of range
+	for _, channel := range []int{1, 24} {
+		select {
+		case p := <-delivered:
+			if got := p.Data(); got[channel-1] != 1 {
+				t.Errorf("Wrong frame was delivered for channel %v: %v", channel, got[:30])
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("The change of channel %v was not delivered!", channel)
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	if len(delivered) != 0 {
+		t.Error("A change outside of the channels of interest should have been suppressed!")
+	}
+
+	//reset to the full universe
+	if err := r.SetInterest(1, 1, 512); err != nil {
+		t.Fatal(err)
+	}
+	data[511]++
+	p = p.copy()
+	p.SetSequence(5)
+	p.SetData(data)
+	r.handle(p)
+	select {
+	case <-delivered:
+	case <-time.After(time.Second):
+		t.Error("After the reset every change should have been delivered!")
+	}
+
+	for _, args := range [][3]int{{0, 1, 24}, {1, 0, 24}, {1, 25, 24}, {1, 1, 513}} {
+		if err := r.SetInterest(uint16(args[0]), args[1], args[2]); err == nil {
+			t.Errorf("SetInterest%v should have returned an error!", args)
+		}
+	}
+}
+
+func TestNewUniverseCallback(t *testing.T) {
+	r := newTestReceiver()
+	universes := make(chan uint16, 10)
+	r.SetNewUniverseCallback(func(universe uint16) {
+		universes <- universe
+	})
+	p := NewDataPacket()
+	p.SetUniverse(7)
+	r.handle(p)
+	p.SequenceIncr()
+	p.SetData([]byte{1})
+	r.handle(p) //no new universe
+	terminated := p.copy()
+	terminated.SequenceIncr()
+	terminated.SetStreamTerminated(true)
+	r.handle(terminated)
+	p.SetSequence(10)
+	r.handle(p) //the terminated universe is new again
+
+	for i := 0; i < 2; i++ {
+		select {
+		case univ := <-universes:
+			if univ != 7 {
+				t.Errorf("Wrong universe! Was: %v", univ)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("The new universe was not reported!")
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	if len(universes) != 0 {
+		t.Errorf("A known universe was reported as new! Was: %v", <-universes)
+	}
+}
+
+func TestSetIdleTTL(t *testing.T) {
+	r := newTestReceiver()
+	r.SetTimeout(10 * time.Millisecond)
+	r.SetIdleTTL(30 * time.Millisecond)
+	r.OnData(2, func(p DataPacket) {})
+	r.joined[3] = true
+	for univ := uint16(1); univ <= 3; univ++ {
+		p := NewDataPacket()
+		p.SetUniverse(univ)
+		r.handle(p)
+	}
+	time.Sleep(20 * time.Millisecond)
+	r.checkForTimeouts()
+	if len(r.lastDatas) != 3 {
+		t.Errorf("Universes were removed before the idle TTL! Was: %v", len(r.lastDatas))
+	}
+	time.Sleep(20 * time.Millisecond)
+	r.checkForTimeouts()
+	if _, ok := r.lastDatas[1]; ok {
+		t.Error("The idle universe was not removed!")
+	}
+	if _, ok := r.lastDatas[2]; !ok {
+		t.Error("The universe with a handler was removed!")
+	}
+	if _, ok := r.lastDatas[3]; !ok {
+		t.Error("The joined universe was removed!")
+	}
+}
@@ -0,0 +1,187 @@
+package sacn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//ReceiverBinding is one bind address together with the interface that is used for joining the
+//multicast-groups, see NewMultiReceiver
+type ReceiverBinding struct {
+	Bind      string
+	Interface *net.Interface
+}
+
+/*
+NewMultiReceiver creates a receiver that receives on multiple bind addresses at once, eg on every
+network interface of redundant lighting networks. The packets of all sockets are handled by the
+returned receiver like the packets of a single socket, so OnData and all callbacks get the data of
+all networks. Like for every receiver, data packets that arrive more than once, eg via multicast on
+multiple interfaces, are only handled once.
+
+JoinUniverse joins the multicast-group on every binding that has an interface. SetMulticastInterface
+and SetReadBuffer are not supported for multiple sockets.
+*/
+func NewMultiReceiver(bindings ...ReceiverBinding) (*ReceiverSocket, error) {
+	if len(bindings) == 0 {
+		return nil, fmt.Errorf("at least one binding is needed")
+	}
+	conns := make([]packetConn, 0, len(bindings))
+	ifis := make([]*net.Interface, 0, len(bindings))
+	var multicastInterface *net.Interface //any interface, so that the receiver allows multicast
+	for _, binding := range bindings {
+		err := checkBind("udp4", binding.Bind, binding.Interface)
+		var conn net.PacketConn
+		if err == nil {
+			conn, err = net.ListenPacket("udp4", net.JoinHostPort(binding.Bind, strconv.Itoa(defaultPort)))
+		}
+		if err != nil {
+			for _, c := range conns {
+				c.Close()
+			}
+			return nil, err
+		}
+		conns = append(conns, newIPv4Conn(conn))
+		ifis = append(ifis, binding.Interface)
+		if multicastInterface == nil {
+			multicastInterface = binding.Interface
+		}
+	}
+	r := newReceiverSocket(context.Background(), multicastInterface)
+	r.socket = newMultiConn(conns, ifis)
+	return r, nil
+}
+
+//errConnClosed is returned by a closed multiConn
+var errConnClosed = errors.New("use of closed connection")
+
+//deadlineError is returned by a multiConn, if the deadline was exceeded
+type deadlineError struct{}
+
+func (deadlineError) Error() string   { return "i/o timeout" }
+func (deadlineError) Timeout() bool   { return true }
+func (deadlineError) Temporary() bool { return true }
+
+//multiRead is the result of one read of a connection of the multiConn
+type multiRead struct {
+	data []byte
+	dst  net.IP
+	addr net.Addr
+	err  error
+}
+
+//multiConn is a packetConn that reads from multiple connections. Every connection joins the
+//multicast-groups with its own interface.
+type multiConn struct {
+	conns     []packetConn
+	ifis      []*net.Interface
+	reads     chan multiRead
+	closed    chan struct{}
+	closeOnce sync.Once
+	deadline  atomic.Value //the deadline as time.Time
+}
+
+func newMultiConn(conns []packetConn, ifis []*net.Interface) *multiConn {
+	c := &multiConn{
+		conns:  conns,
+		ifis:   ifis,
+		reads:  make(chan multiRead),
+		closed: make(chan struct{}),
+	}
+	c.deadline.Store(time.Time{})
+	for _, conn := range conns {
+		go c.read(conn)
+	}
+	return c
+}
+
+//read reads from the connection until the multiConn is closed. Timeouts of the connection are
+//ignored, the deadline of the multiConn is handled by ReadFrom.
+func (c *multiConn) read(conn packetConn) {
+	for {
+		buf := make([]byte, maxPacketSize+1)
+		n, dst, addr, err := conn.ReadFrom(buf)
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			continue
+		}
+		select {
+		case c.reads <- multiRead{buf[:n], dst, addr, err}:
+		case <-c.closed:
+			return
+		}
+	}
+}
+
+func (c *multiConn) ReadFrom(b []byte) (int, net.IP, net.Addr, error) {
+	var timeout <-chan time.Time
+	if deadline := c.deadline.Load().(time.Time); !deadline.IsZero() {
+		timer := time.NewTimer(time.Until(deadline))
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case read := <-c.reads:
+		if read.err != nil {
+			return 0, nil, read.addr, read.err
+		}
+		return copy(b, read.data), read.dst, read.addr, nil
+	case <-timeout:
+		return 0, nil, nil, deadlineError{}
+	case <-c.closed:
+		return 0, nil, nil, errConnClosed
+	}
+}
+
+func (c *multiConn) SetDeadline(t time.Time) error {
+	c.deadline.Store(t)
+	return nil
+}
+
+//JoinGroup joins the multicast-group on every connection that has an interface. The given interface
+//is ignored.
+func (c *multiConn) JoinGroup(ifi *net.Interface, universe uint16) error {
+	var firstErr error
+	for i, conn := range c.conns {
+		if c.ifis[i] == nil {
+			continue
+		}
+		if err := conn.JoinGroup(c.ifis[i], universe); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+//LeaveGroup leaves the multicast-group on every connection that has an interface. The given interface
+//is ignored.
+func (c *multiConn) LeaveGroup(ifi *net.Interface, universe uint16) error {
+	var firstErr error
+	for i, conn := range c.conns {
+		if c.ifis[i] == nil {
+			continue
+		}
+		if err := conn.LeaveGroup(c.ifis[i], universe); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (c *multiConn) Close() error {
+	var firstErr error
+	c.closeOnce.Do(func() {
+		close(c.closed)
+		for _, conn := range c.conns {
+			if err := conn.Close(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	})
+	return firstErr
+}
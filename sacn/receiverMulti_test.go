@@ -0,0 +1,90 @@
+package sacn
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMultiConn(t *testing.T) {
+	p := NewDataPacket()
+	p.SetUniverse(1)
+	p.SetSequence(1)
+	p.SetData([]byte{1})
+	second := p.copy()
+	second.SetSequence(2)
+	second.SetData([]byte{2})
+	//the first packet arrives on both networks, the second one only on the second network
+	conn1 := &fakeConn{reads: []fakeRead{{data: p.Bytes()}}}
+	conn2 := &fakeConn{reads: []fakeRead{{data: p.Bytes()}, {data: second.Bytes()}}}
+	ifis := []*net.Interface{{Index: 1, Name: "net1"}, {Index: 2, Name: "net2"}}
+
+	r := newTestReceiver()
+	r.socket = newMultiConn([]packetConn{conn1, conn2}, ifis)
+	r.multicastInterface = ifis[0]
+	delivered := make(chan DataPacket, 10)
+	r.OnData(1, func(p DataPacket) {
+		delivered <- p
+	})
+	r.Start()
+	defer r.Close()
+
+	//the networks are read concurrently, so the order of the packets is not fixed
+	got := make(map[byte]bool)
+	for len(got) < 2 {
+		select {
+		case p := <-delivered:
+			got[p.Data()[0]] = true
+		case <-time.After(time.Second):
+			t.Fatalf("Not all data was delivered! Got: %v", got)
+		}
+	}
+	time.Sleep(20 * time.Millisecond)
+	if stats := r.Stats(); stats.PacketsReceived != 3 || stats.Delivered != 2 {
+		t.Errorf("The duplicate packet was not dropped! Stats: %+v", stats)
+	}
+
+	//every connection joins with its own interface
+	if err := r.JoinUniverse(1); err != nil {
+		t.Fatal(err)
+	}
+	for i, conn := range []*fakeConn{conn1, conn2} {
+		conn.mu.Lock()
+		events := conn.events
+		conn.mu.Unlock()
+		if want := []string{"join " + ifis[i].Name + " 1"}; !reflect.DeepEqual(events, want) {
+			t.Errorf("Wrong joins on connection %v! Was: %v", i, events)
+		}
+	}
+}
+
+func TestNewMultiReceiver(t *testing.T) {
+	if _, err := NewMultiReceiver(); err == nil {
+		t.Error("A receiver without bindings should not be created!")
+	}
+	r, err := NewMultiReceiver(ReceiverBinding{Bind: "127.0.0.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	delivered := make(chan DataPacket, 1)
+	r.OnData(1, func(p DataPacket) {
+		delivered <- p
+	})
+	r.Start()
+
+	conn, err := net.Dial("udp4", "127.0.0.1:5568")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	p := NewDataPacket()
+	p.SetUniverse(1)
+	conn.Write(p.Bytes())
+	select {
+	case <-delivered:
+	case <-time.After(time.Second):
+		t.Error("Data was not received!")
+	}
+}
@@ -0,0 +1,123 @@
+package sacn
+
+import (
+	"time"
+)
+
+//perAddressSource holds the state of one source on a universe that uses per-address priorities
+type perAddressSource struct {
+	priorities [512]byte  //the priorities of the last 0xDD packet
+	prioTime   time.Time  //the time of the last 0xDD packet, zero if the source never sent one
+	prioSequ   byte       //the sequence number of the last 0xDD packet
+	lastPacket DataPacket //the last packet with DMX data
+	lastTime   time.Time  //the time of the last packet with DMX data, zero if there was none
+}
+
+//channelPriorities returns the priority of every channel of this source
+func (s *perAddressSource) channelPriorities(now time.Time, timeout time.Duration) [512]byte {
+	if !s.prioTime.IsZero() && now.Sub(s.prioTime) <= timeout {
+		return s.priorities
+	}
+	return s.lastPacket.PerChannelPriority()
+}
+
+//handlePerAddressPriority stores the priorities of a packet with the start code 0xDD.
+//From now on the DMX data of the universe is merged channel by channel.
+func (r *ReceiverSocket) handlePerAddressPriority(p DataPacket) {
+	sources, ok := r.perAddress[p.Universe()]
+	if !ok {
+		sources = make(map[[16]byte]*perAddressSource)
+		r.perAddress[p.Universe()] = sources
+	}
+	src, ok := sources[p.CID()]
+	if !ok {
+		src = &perAddressSource{}
+		sources[p.CID()] = src
+	}
+	if !src.prioTime.IsZero() && !r.ignoreSequence && !checkSequ(src.prioSequ, p.Sequence()) {
+		return //out of order, so the priorities are older than the stored ones
+	}
+	src.priorities = p.PerChannelPriority()
+	src.prioTime = r.now()
+	src.prioSequ = p.Sequence()
+}
+
+//usesPerAddressPriority returns true, if any source on the universe has sent per-address priorities
+//within the timeout
+func (r *ReceiverSocket) usesPerAddressPriority(universe uint16) bool {
+	for _, src := range r.perAddress[universe] {
+		if !src.prioTime.IsZero() && r.now().Sub(src.prioTime) <= r.Timeout() {
+			return true
+		}
+	}
+	return false
+}
+
+//processPerAddress stores the DMX data of the source and merges the data of all sources. Every
+//channel is taken from the source with the highest priority for this channel. If two sources have the
+//same priority for a channel, the higher value is used. As defined by E1.31, the per-address priority 0
+//means that the source does not send the channel at all, so it never wins the channel, even if it is
+//the only source. Channels that no source sends are 0 in the merged data.
+func (r *ReceiverSocket) processPerAddress(p DataPacket) {
+	sources := r.perAddress[p.Universe()]
+	src, ok := sources[p.CID()]
+	if !ok {
+		src = &perAddressSource{}
+		sources[p.CID()] = src
+	}
+	if !src.lastTime.IsZero() && !r.checkSequence(src.lastPacket, p) {
+		return //out of order packet of this source
+	}
+	src.lastPacket = p.copy()
+	src.lastTime = r.now()
+
+	merged := r.mergePerAddress(p)
+	last, ok := r.lastDatas[p.Universe()]
+	if !ok || r.deliverUnchanged || r.dataChanged(p.Universe(), last.lastPacket.Data(), merged.Data()) {
+		r.invokeCallback(merged)
+	}
+	r.storeLastPacket(merged)
+}
+
+//mergePerAddress merges the data of all sources of the universe of the given packet. The returned
+//packet is a copy of the given one with the merged data.
+func (r *ReceiverSocket) mergePerAddress(p DataPacket) DataPacket {
+	data := [512]byte{}
+	prios := [512]byte{}
+	length := 0
+	for _, src := range r.perAddress[p.Universe()] {
+		if src.lastTime.IsZero() || r.now().Sub(src.lastTime) > r.Timeout() {
+			continue //the source has no valid DMX data
+		}
+		srcPrios := src.channelPriorities(r.now(), r.Timeout())
+		for i, value := range src.lastPacket.Data() {
+			if srcPrios[i] == 0 || srcPrios[i] < prios[i] {
+				continue //the channel is not patched on this source or another source wins
+			}
+			if srcPrios[i] > prios[i] || value > data[i] {
+				data[i] = value
+				prios[i] = srcPrios[i]
+			}
+			if i+1 > length {
+				length = i + 1
+			}
+		}
+	}
+	merged := p.copy()
+	merged.SetData(data[:length])
+	return merged
+}
+
+//removePerAddressTimeouts removes all sources that have not sent anything within the timeout
+func (r *ReceiverSocket) removePerAddressTimeouts() {
+	for univ, sources := range r.perAddress {
+		for cid, src := range sources {
+			if r.now().Sub(src.prioTime) > r.Timeout() && r.now().Sub(src.lastTime) > r.Timeout() {
+				delete(sources, cid)
+			}
+		}
+		if len(sources) == 0 {
+			delete(r.perAddress, univ)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package sacn
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestProcessPerAddress(t *testing.T) {
+	r := newTestReceiver()
+	changed := make(chan struct{}, 10)
+	r.SetOnChangeCallback(func(old, new DataPacket) {
+		changed <- struct{}{}
+	})
+	newPacket := func(cid byte, startCode byte, data []byte) DataPacket {
+		p := NewDataPacket()
+		p.SetUniverse(1)
+		p.SetCID([16]byte{cid})
+		p.SetDmxStartCode(startCode)
+		p.SetData(data)
+		return p
+	}
+	//source 1 wins channel 1 and 3, source 2 wins channel 2
+	r.handle(newPacket(1, 0xDD, []byte{150, 50, 100, 0}))
+	r.handle(newPacket(2, 0xDD, []byte{100, 100, 100, 0}))
+	if _, ok := r.lastDatas[1]; ok {
+		t.Fatal("Priority packets should not be handled as DMX data!")
+	}
+	r.handle(newPacket(1, 0, []byte{10, 10, 10, 10}))
+	r.handle(newPacket(2, 0, []byte{20, 20, 5, 20}))
+
+	select {
+	case <-changed:
+	case <-time.After(time.Second):
+		t.Fatal("Callback was not called!")
+	}
+	got := r.lastDatas[1].lastPacket
+	//channel 3 has the same priority on both sources, so the higher value wins
+	//channel 4 has the priority 0 on both sources, so it is not patched
+	if !bytes.Equal(got.Data(), []byte{10, 20, 10, 0}) {
+		t.Errorf("Wrong merged data! Was: %v", got.Data())
+	}
+}
+
+func TestPerAddressTimeout(t *testing.T) {
+	r := newTestReceiver()
+	r.SetTimeout(10 * time.Millisecond)
+	p := NewDataPacket()
+	p.SetUniverse(1)
+	p.SetDmxStartCode(0xDD)
+	p.SetData([]byte{100})
+	r.handle(p)
+	if !r.usesPerAddressPriority(1) {
+		t.Fatal("Universe should use per-address priorities!")
+	}
+	time.Sleep(20 * time.Millisecond)
+	if r.usesPerAddressPriority(1) {
+		t.Error("Per-address priorities should have timed out!")
+	}
+	r.checkForTimeouts()
+	if _, ok := r.perAddress[1]; ok {
+		t.Error("Timed out sources should have been removed!")
+	}
+}
+
+func TestPerAddressStaleSource(t *testing.T) {
+	r := newTestReceiver()
+	r.SetTimeout(20 * time.Millisecond)
+	newPacket := func(cid byte, startCode byte, data []byte) DataPacket {
+		p := NewDataPacket()
+		p.SetUniverse(1)
+		p.SetCID([16]byte{cid})
+		p.SetDmxStartCode(startCode)
+		p.SetData(data)
+		return p
+	}
+	r.handle(newPacket(1, 0xDD, []byte{200}))
+	r.handle(newPacket(1, 0, []byte{10}))
+	time.Sleep(30 * time.Millisecond)
+	//source 1 is stale now, so source 2 takes over although its priority is lower
+	r.handle(newPacket(2, 0xDD, []byte{100}))
+	r.handle(newPacket(2, 0, []byte{20}))
+	got := r.lastDatas[1].lastPacket
+	if got.Data()[0] != 20 {
+		t.Errorf("Stale source was still used! Was: %v", got.Data()[0])
+	}
+	r.checkForTimeouts()
+	if _, ok := r.perAddress[1][[16]byte{1}]; ok {
+		t.Error("Stale source should have been deleted!")
+	}
+}
+
+func TestPerAddressRestartedStream(t *testing.T) {
+	r := newTestReceiver()
+	delivered := make(chan DataPacket, 10)
+	r.OnData(1, func(p DataPacket) {
+		delivered <- p
+	})
+	prio := NewDataPacket()
+	prio.SetUniverse(1)
+	prio.SetDmxStartCode(0xDD)
+	prio.SetData([]byte{100})
+	p := NewDataPacket()
+	p.SetUniverse(1)
+	p.SetData([]byte{1})
+	r.handle(prio)
+	for i := 0; i < 5; i++ {
+		p.SequenceIncr()
+		r.handle(p)
+	}
+	<-delivered
+	terminated := p.copy()
+	terminated.SetStreamTerminated(true)
+	r.handle(terminated)
+
+	//the restarted stream begins again with a low sequence number and has to be delivered immediately
+	p.SetSequence(0)
+	p.SetData([]byte{2})
+	r.handle(prio)
+	r.handle(p)
+	select {
+	case got := <-delivered:
+		if got.Data()[0] != 2 {
+			t.Errorf("Wrong data after the restart! Was: %v", got.Data())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("The restarted stream was dropped!")
+	}
+}
+
+func TestPerAddressPrioritySequence(t *testing.T) {
+	r := newTestReceiver()
+	prios := func(sequence, prio byte) DataPacket {
+		p := NewDataPacket()
+		p.SetUniverse(1)
+		p.SetDmxStartCode(StartCodePerAddressPriority)
+		p.SetSequence(sequence)
+		p.SetData([]byte{prio, 0})
+		return p
+	}
+	r.handle(prios(10, 200))
+	r.handle(prios(9, 50)) //reordered, so it is older than the last one
+	if got := r.perAddress[1][[16]byte{}].priorities[0]; got != 200 {
+		t.Errorf("An out of order priority packet has overwritten the priorities! Was: %v", got)
+	}
+	r.handle(prios(11, 50))
+	if got := r.perAddress[1][[16]byte{}].priorities[0]; got != 50 {
+		t.Errorf("The new priorities were not used! Was: %v", got)
+	}
+
+	//the priority 0 is never used, even for the only source
+	p := NewDataPacket()
+	p.SetUniverse(1)
+	p.SetData([]byte{7, 7})
+	r.handle(p)
+	if got := r.lastDatas[1].lastPacket; got.Data()[0] != 7 || got.Data()[1] != 0 {
+		t.Errorf("A channel with the priority 0 was used! Data: %v", got.Data())
+	}
+}
@@ -1,9 +1,11 @@
 package sacn_test
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net"
+	"testing"
 	"time"
 
 	"github.com/Hundemeier/go-sacn/sacn"
@@ -46,3 +48,225 @@ func ExampleReceiverSocket_multicast() {
 	fmt.Println("Leaved")
 	select {} //only that our program does not exit. Exit with Ctrl+C
 }
+
+func TestReceiverSocketClose(t *testing.T) {
+	recv, err := sacn.NewReceiverSocket("127.0.0.1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recv.Start()
+	if err := recv.Close(); err != nil {
+		t.Errorf("First close should not fail, got: %v", err)
+	}
+	if err := recv.Close(); err != nil {
+		t.Errorf("Second close should be a no-op, got: %v", err)
+	}
+	recv.Start() //starting a closed receiver should do nothing
+	if err := recv.Close(); err != nil {
+		t.Errorf("Close after Start on a closed receiver should be a no-op, got: %v", err)
+	}
+}
+
+func TestReceiverSocketContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	recv, err := sacn.NewReceiverSocketContext(ctx, "127.0.0.1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recv.Start()
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		recv.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("Receiver did not stop after the context was cancelled")
+	}
+}
+
+func TestJoinUniverseWithoutInterface(t *testing.T) {
+	recv, err := sacn.NewReceiverSocket("127.0.0.1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer recv.Close()
+	if err := recv.JoinUniverse(1); err != sacn.ErrNoMulticastInterface {
+		t.Errorf("Joining without a multicast interface should fail! Was: %v", err)
+	}
+	if err := recv.LeaveUniverse(1); err != nil {
+		t.Errorf("Leaving a not joined universe should do nothing, got: %v", err)
+	}
+}
+
+func TestNewReceiverSocketPort(t *testing.T) {
+	recv, err := sacn.NewReceiverSocketPort("127.0.0.1", 5569, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer recv.Close()
+	changed := make(chan sacn.DataPacket, 1)
+	recv.SetOnChangeCallback(func(old sacn.DataPacket, newD sacn.DataPacket) {
+		changed <- newD
+	})
+	recv.Start()
+
+	conn, err := net.Dial("udp4", "127.0.0.1:5569")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	p := sacn.NewDataPacket()
+	p.SetUniverse(3)
+	p.SetData([]byte{1, 2, 3})
+	if _, err := conn.Write(p.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case got := <-changed:
+		if got.Universe() != 3 {
+			t.Errorf("Wrong universe! Was: %v", got.Universe())
+		}
+		if addr, ok := got.SourceAddr().(*net.UDPAddr); !ok || !addr.IP.Equal(conn.LocalAddr().(*net.UDPAddr).IP) {
+			t.Errorf("Wrong source address! Was: %v", got.SourceAddr())
+		}
+		//the destination is only known, if the operating system supports the control messages
+		if dst := got.DestinationAddr(); dst != nil && (dst.IsMulticast() || !dst.Equal(net.IPv4(127, 0, 0, 1))) {
+			t.Errorf("Wrong destination address! Was: %v", dst)
+		}
+	case <-time.After(time.Second):
+		t.Error("Packet was not received on the custom port!")
+	}
+}
+
+func TestNewReceiverSocketBind(t *testing.T) {
+	if _, err := sacn.NewReceiverSocketPort("not-a-valid-host.invalid", 5569, nil); err == nil {
+		t.Error("Unresolvable bind address should be rejected!")
+	}
+	lo, err := net.InterfaceByName("lo")
+	if err != nil {
+		t.Skip("no loopback interface named lo")
+	}
+	recv, err := sacn.NewReceiverSocketPort("127.0.0.1", 5569, lo)
+	if err != nil {
+		t.Fatalf("Loopback address should belong to the loopback interface: %v", err)
+	}
+	recv.Close()
+	if _, err := sacn.NewReceiverSocketPort("127.0.0.2", 5569, lo); err == nil {
+		t.Error("Address that does not belong to the interface should be rejected!")
+	}
+}
+
+func TestNewReceiverSocketIPv6(t *testing.T) {
+	recv, err := sacn.NewReceiverSocketIPv6("::1", nil)
+	if err != nil {
+		t.Skipf("IPv6 loopback is not available: %v", err)
+	}
+	defer recv.Close()
+	changed := make(chan sacn.DataPacket, 1)
+	recv.SetOnChangeCallback(func(old sacn.DataPacket, newD sacn.DataPacket) {
+		changed <- newD
+	})
+	recv.Start()
+
+	conn, err := net.Dial("udp6", "[::1]:5568")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	p := sacn.NewDataPacket()
+	p.SetUniverse(4)
+	if _, err := conn.Write(p.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case got := <-changed:
+		if got.Universe() != 4 {
+			t.Errorf("Wrong universe! Was: %v", got.Universe())
+		}
+	case <-time.After(time.Second):
+		t.Error("Packet was not received via IPv6!")
+	}
+}
+
+//packetConn hides the type of the udp socket, so that the receiver has to use it as plain connection
+type packetConn struct {
+	net.PacketConn
+}
+
+func TestNewReceiverSocketConn(t *testing.T) {
+	for _, wrap := range []bool{false, true} {
+		udp, err := net.ListenPacket("udp4", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn := udp
+		if wrap {
+			conn = packetConn{udp}
+		}
+		recv := sacn.NewReceiverSocketConn(conn, &net.Interface{Index: 1, Name: "test"})
+		if wrap {
+			if err := recv.JoinUniverse(1); err != nil {
+				t.Errorf("Joining should do nothing for plain connections, got: %v", err)
+			}
+		}
+		changed := make(chan sacn.DataPacket, 1)
+		recv.SetOnChangeCallback(func(old sacn.DataPacket, newD sacn.DataPacket) {
+			changed <- newD
+		})
+		recv.Start()
+
+		sender, err := net.Dial("udp4", udp.LocalAddr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		p := sacn.NewDataPacket()
+		p.SetUniverse(6)
+		if _, err := sender.Write(p.Bytes()); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case got := <-changed:
+			if got.Universe() != 6 {
+				t.Errorf("Wrong universe! Was: %v", got.Universe())
+			}
+		case <-time.After(time.Second):
+			t.Error("Packet was not received on the given connection!")
+		}
+		sender.Close()
+		recv.Close()
+	}
+}
+
+func TestSetReadBuffer(t *testing.T) {
+	recv, err := sacn.NewReceiverSocketPort("127.0.0.1", 5569, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer recv.Close()
+	small, err := recv.SetReadBuffer(4096)
+	if err != nil {
+		t.Fatal(err)
+	}
+	//a high universe count: 200 universes with 4 frames of the biggest data packets
+	big, err := recv.SetReadBuffer(200 * 638 * 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if small <= 0 || big <= small {
+		t.Errorf("The read buffer was not increased! Was: %v, then %v", small, big)
+	}
+
+	udp, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain := sacn.NewReceiverSocketConn(packetConn{udp}, nil)
+	defer plain.Close()
+	if _, err := plain.SetReadBuffer(4096); err == nil {
+		t.Error("Setting the read buffer of a plain connection should fail!")
+	}
+}
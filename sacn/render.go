@@ -0,0 +1,47 @@
+package sacn
+
+import (
+	"fmt"
+	"strings"
+)
+
+//renderLevels are the characters for the channel levels of RenderBar, from 0 to full
+const renderLevels = " .:-=+*#%@"
+
+//renderRowLength is the count of channels in one row of RenderBar
+const renderRowLength = 32
+
+/*
+RenderBar renders the levels of the first channels of the DMX data as ASCII heatmap, eg for monitoring
+a universe in a terminal. Every channel is one character from " " (0) over ".:-=+*#%" to "@" (255),
+and every row shows 32 channels, prefixed with the number of its first channel:
+
+	 1 |@@%#*+=-:.                      |
+	33 |                                |
+
+Channels that are not in the data are rendered as 0. If channels is 0 or less, all channels of the data
+are rendered; at most 512 channels are rendered. The last row is shorter, if channels is no multiple
+of 32. The returned string ends with a newline.
+*/
+func RenderBar(data []byte, channels int) string {
+	if channels <= 0 {
+		channels = len(data)
+	}
+	if channels > 512 {
+		channels = 512
+	}
+	var b strings.Builder
+	for row := 0; row < channels; row += renderRowLength {
+		fmt.Fprintf(&b, "%3d |", row+1)
+		for i := row; i < row+renderRowLength && i < channels; i++ {
+			var value byte
+			if i < len(data) {
+				value = data[i]
+			}
+			//round up, so that only a channel at 0 is blank
+			b.WriteByte(renderLevels[(int(value)*(len(renderLevels)-1)+254)/255])
+		}
+		b.WriteString("|\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,56 @@
+package sacn
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func ExampleRenderBar() {
+	data := make([]byte, 24)
+	for i := range data {
+		data[i] = byte(255 - i*11)
+	}
+	fmt.Print(RenderBar(data, 24))
+	// Output:
+	//   1 |@@@%%%##***++===---::...|
+}
+
+//this is a small monitor for the terminal, that shows the data of universe 1 and updates it live
+func ExampleRenderBar_monitor() {
+	recv, err := NewReceiverSocket("", nil)
+	if err != nil {
+		panic(err)
+	}
+	defer recv.Close()
+	recv.OnData(1, func(p DataPacket) {
+		//clear the terminal and move the cursor to the top left corner, before drawing the data
+		fmt.Print("\033[H\033[2J")
+		fmt.Printf("universe %v from %q, priority %v\n", p.Universe(), p.SourceName(), p.Priority())
+		fmt.Print(RenderBar(p.Data(), 512))
+	})
+	recv.SetTimeoutCallback(func(universe uint16) {
+		fmt.Println("timeout on universe", universe)
+	})
+	recv.Start()
+	select {} //only that our program does not exit. Exit with Ctrl+C
+}
+
+func TestRenderBar(t *testing.T) {
+	out := RenderBar([]byte{0, 1, 28, 29, 128, 254, 255}, 0)
+	if out != "  1 | ..:+@@|\n" {
+		t.Errorf("Wrong output! Was: %q", out)
+	}
+	//missing channels are 0 and every row has 32 channels
+	out = RenderBar([]byte{255}, 40)
+	want := "  1 |@" + strings.Repeat(" ", 31) + "|\n 33 |" + strings.Repeat(" ", 8) + "|\n"
+	if out != want {
+		t.Errorf("Wrong output!\nWas:            %q\nShould've been: %q", out, want)
+	}
+	if rows := strings.Count(RenderBar(nil, 1000), "\n"); rows != 16 {
+		t.Errorf("At most 512 channels should be rendered, got %v rows", rows)
+	}
+	if out := RenderBar(nil, 0); out != "" {
+		t.Errorf("Empty data should render nothing, got: %q", out)
+	}
+}
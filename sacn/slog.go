@@ -0,0 +1,46 @@
+//go:build go1.21
+// +build go1.21
+
+package sacn
+
+import (
+	"context"
+	"log/slog"
+)
+
+//slogLogger is the Logger that is returned by SlogLogger
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+//SlogLogger returns a Logger that writes the events of the receiver as structured records to the given
+//*slog.Logger. The universe, CID, address and error of an event are added as attributes. If logger is
+//nil, nil is returned, so that nothing is logged and no arguments are built.
+//Note that a *slog.Logger can also be used as Logger directly, this adapter only avoids the work for
+//records whose level is not enabled.
+func SlogLogger(logger *slog.Logger) Logger {
+	if logger == nil {
+		return nil
+	}
+	return slogLogger{logger}
+}
+
+func (s slogLogger) Debug(msg string, args ...interface{}) { s.log(slog.LevelDebug, msg, args) }
+func (s slogLogger) Info(msg string, args ...interface{})  { s.log(slog.LevelInfo, msg, args) }
+func (s slogLogger) Warn(msg string, args ...interface{})  { s.log(slog.LevelWarn, msg, args) }
+
+func (s slogLogger) log(level slog.Level, msg string, args []interface{}) {
+	ctx := context.Background()
+	if !s.logger.Enabled(ctx, level) {
+		return
+	}
+	attrs := make([]slog.Attr, 0, len(args)/2)
+	for i := 0; i+1 < len(args); i += 2 {
+		key, ok := args[i].(string)
+		if !ok {
+			key = "!BADKEY"
+		}
+		attrs = append(attrs, slog.Any(key, args[i+1]))
+	}
+	s.logger.LogAttrs(ctx, level, msg, attrs...)
+}
@@ -0,0 +1,41 @@
+//go:build go1.21
+// +build go1.21
+
+package sacn
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+)
+
+func ExampleSlogLogger() {
+	recv, err := NewReceiverSocket("", nil)
+	if err != nil {
+		panic(err)
+	}
+	defer recv.Close()
+	//log warnings and more important events of the receiver as JSON
+	handler := slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn})
+	recv.SetLogger(SlogLogger(slog.New(handler).With("component", "sacn")))
+	recv.Start()
+}
+
+func TestSlogLogger(t *testing.T) {
+	if SlogLogger(nil) != nil {
+		t.Error("A nil *slog.Logger should result in no logger!")
+	}
+	var buf bytes.Buffer
+	logger := SlogLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo})))
+	logger.Debug("not logged", "universe", uint16(1))
+	logger.Info("universe timed out", "universe", uint16(1), "cid", CIDString([16]byte{1}))
+	out := buf.String()
+	if strings.Contains(out, "not logged") {
+		t.Errorf("Debug record was logged with level info: %v", out)
+	}
+	if !strings.Contains(out, `level=INFO msg="universe timed out" universe=1 cid=01000000-0000-0000-0000-000000000000`) {
+		t.Errorf("Wrong record! Was: %v", out)
+	}
+}
@@ -0,0 +1,92 @@
+package sacn
+
+import "fmt"
+
+const vectorE131ExtendedSynchronization = 1 //VECTOR_E131_EXTENDED_SYNCHRONIZATION
+
+//SyncPacket is a synchronization packet. Receivers hold back the data packets that have the sync
+//address of this packet set, until this packet arrives. Then all of them are processed at once.
+type SyncPacket struct {
+	data []byte
+}
+
+//NewSyncPacket creates a new SyncPacket with the sync address 0
+func NewSyncPacket() SyncPacket {
+	p := SyncPacket{make([]byte, 49)}
+	//Set constants: at index [0;16[
+	copy(p.data, constHeader)
+	//Set vectors:
+	copy(p.data[18:], getAsBytes32(vectorRootE131Extended))
+	copy(p.data[40:], getAsBytes32(vectorE131ExtendedSynchronization))
+	//Set the FAL values, the length of this packet is always the same
+	rootFAL := calculateFal(49 - 16)
+	copy(p.data[16:], rootFAL[:])
+	framingFAL := calculateFal(49 - 38)
+	copy(p.data[38:], framingFAL[:])
+	return p
+}
+
+//NewSyncPacketRaw creates a new SyncPacket based on the given raw bytes. Returns an error,
+//if the bytes are not a valid synchronization packet.
+func NewSyncPacketRaw(raw []byte) (SyncPacket, error) {
+	var p SyncPacket
+	if len(raw) != 49 {
+		return p, fmt.Errorf("the length of a synchronization packet has to be 49, was %v", len(raw))
+	}
+	if err := checkPacketIdentifier(raw); err != nil {
+		return p, err
+	}
+	if !isSyncPacket(raw) {
+		return p, fmt.Errorf("the vectors of the packet do not belong to a synchronization packet")
+	}
+	if err := checkLayers(raw, 16, 38); err != nil {
+		return p, err
+	}
+	p.data = append([]byte(nil), raw...) //make a copy of the slice, we do not want to use a reference
+	return p, nil
+}
+
+//isSyncPacket checks the root and framing vector, if the raw bytes are a synchronization packet
+func isSyncPacket(raw []byte) bool {
+	return len(raw) >= 44 &&
+		getAsUint32(raw[18:22]) == vectorRootE131Extended &&
+		getAsUint32(raw[40:44]) == vectorE131ExtendedSynchronization
+}
+
+//SetCID sets the CID unique identifier
+func (s *SyncPacket) SetCID(cid [16]byte) {
+	copy(s.data[22:38], cid[:])
+}
+
+//CID returns the cid of the source that sends this packet
+func (s *SyncPacket) CID() [16]byte {
+	tmpArray := [16]byte{}
+	copy(tmpArray[:], s.data[22:38])
+	return tmpArray
+}
+
+//SetSequence sets the sequence number of the packet
+func (s *SyncPacket) SetSequence(sequ byte) {
+	s.data[44] = sequ
+}
+
+//Sequence returns the sequence number of the packet
+func (s *SyncPacket) Sequence() byte {
+	return s.data[44]
+}
+
+//SetSyncAddress sets the universe on which this packet is send
+func (s *SyncPacket) SetSyncAddress(sync uint16) {
+	copy(s.data[45:47], getAsBytes16(sync))
+}
+
+//SyncAddress returns the universe on which this packet is send. All data packets with this
+//sync address are synchronized by this packet.
+func (s *SyncPacket) SyncAddress() uint16 {
+	return uint16(getAsUint32(s.data[45:47]))
+}
+
+//Bytes returns the packet as it is send over the network. The returned slice is a copy.
+func (s *SyncPacket) Bytes() []byte {
+	return append([]byte(nil), s.data...)
+}
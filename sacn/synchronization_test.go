@@ -0,0 +1,54 @@
+package sacn
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNewSyncPacket(t *testing.T) {
+	p := NewSyncPacket()
+	b := p.Bytes()
+	if len(b) != 49 {
+		t.Errorf("Wrong length! Was: %v; Should've been: %v", len(b), 49)
+	}
+	if !bytes.Equal(b[18:22], []byte{0, 0, 0, 8}) || !bytes.Equal(b[40:44], []byte{0, 0, 0, 1}) {
+		t.Errorf("Wrong vectors! Was: %v", b)
+	}
+	if b[16] != 0x70 || b[17] != 49-16 || b[38] != 0x70 || b[39] != 49-38 {
+		t.Errorf("Wrong flags and length fields! Was: %v", b)
+	}
+}
+
+func TestSyncPacketRaw(t *testing.T) {
+	p := NewSyncPacket()
+	p.SetCID([16]byte{1, 2})
+	p.SetSequence(42)
+	p.SetSyncAddress(0x1234)
+	if !bytes.Equal(p.data[45:47], []byte{0x12, 0x34}) {
+		t.Errorf("Wrong sync address! Was: %v", p.data[45:47])
+	}
+	parsed, err := NewSyncPacketRaw(p.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.CID() != p.CID() || parsed.Sequence() != 42 || parsed.SyncAddress() != 0x1234 {
+		t.Error("Parsed packet is not equal to the build one!")
+	}
+	if _, err := NewSyncPacketRaw(p.Bytes()[:48]); err == nil {
+		t.Error("Too short packet should be rejected!")
+	}
+	if _, err := NewSyncPacketRaw(append(p.Bytes(), 0)); err == nil {
+		t.Error("Too long packet should be rejected!")
+	}
+	for _, index := range []int{4, 16, 17, 38, 39} {
+		raw := p.Bytes()
+		raw[index]++
+		if _, err := NewSyncPacketRaw(raw); err == nil {
+			t.Errorf("Packet with a changed byte %v should be rejected!", index)
+		}
+	}
+	data := NewDataPacket()
+	if _, err := NewSyncPacketRaw(data.Bytes()); err == nil {
+		t.Error("Data packet should be rejected!")
+	}
+}
@@ -3,36 +3,88 @@ package sacn
 import (
 	"fmt"
 	"net"
+	"sync"
 	"time"
+
+	"golang.org/x/net/ipv4"
 )
 
+//the default rate with which changed data is send out. This is the maximum rate of DMX512
+const defaultFrameRate = 44
+
+//terminatePackets is the count of packets with the stream terminated bit that are send, if a universe
+//is deactivated
+const terminatePackets = 3
+
+//keepAliveInterval is the default interval in which the last data is send out, even if nothing has
+//changed. It is also the longest interval that E1.31 allows.
+const keepAliveInterval = time.Second
+
+//discoveryInterval is the interval in which the universe discovery packets are send out
+const discoveryInterval = 10 * time.Second
+
+//defaultMulticastTTL is the TTL of multicast packets, so that they do not leave the local network
+const defaultMulticastTTL = 1
+
 //Transmitter : This struct is for managing the transmitting of sACN data.
 //It handles all channels and overwatches what universes are already used.
 type Transmitter struct {
+	mu        sync.Mutex //guards all maps and the intervals
 	universes map[uint16]chan [512]byte
+	stop      map[uint16]chan struct{} //closing a stop channel deactivates the universe
+	done      map[uint16]chan struct{} //gets closed, if the goroutine of a universe has finished
 	//master stores the master DataPacket for all univereses. Its the last send out packet
-	master       map[uint16]*DataPacket
-	destinations map[uint16][]net.UDPAddr //holds the info about the destinations unicast or multicast
-	multicast    map[uint16]bool          //stores if an universe should be send out as multicast
-	bind         string                   //stores the string with the binding information
-	cid          [16]byte                 //the global cid for all packets
-	sourceName   string                   //the global source name for all packets
+	master        map[uint16]*DataPacket
+	destinations  map[uint16][]net.UDPAddr //holds the info about the destinations unicast or multicast
+	multicast     map[uint16]bool          //stores if an universe should be send out as multicast
+	bind          string                   //stores the string with the binding information
+	cid           [16]byte                 //the global cid for all packets
+	sourceName    string                   //the global source name for all packets
+	sourceNames   map[uint16]string        //the source names of universes that do not use the global one
+	frameInterval time.Duration            //the minimum time between two packets with changed data
+	keepAlive     time.Duration            //the time after which unchanged data is send again
+	discoveryStop chan struct{}            //stops the universe discovery, nil if it is not running
+	syncAddress   map[uint16]uint16        //the sync address for every universe
+	forceSync     map[uint16]bool          //the universes whose packets have the force_synchronization flag set
+	syncSequence  map[uint16]byte          //the last sequence number for every sync universe
+	sequence      map[uint16]byte          //the last sequence number of deactivated universes
+	multicastTTL  int                      //the TTL for multicast packets of new sockets
+	loopback      bool                     //true, if multicast packets are looped back to this host
+	outputIfi     *net.Interface           //the interface for multicast packets, nil for the default one
+	syncMu        sync.Mutex               //guards the sync socket, it is locked before mu
+	syncConn      *net.UDPConn             //the socket for the sync packets, nil until the first SendSync
 }
 
 //NewTransmitter creates a new Transmitter object and returns it. Only use one object for one
 //network interface. bind is a string like "192.168.2.34" or "". It is used for binding the udpconnection.
 //In most cases an empty string will be sufficient. The caller is responsible for closing!
 //If you want to use multicast, you have to provide a binding string on some operation systems (eg Windows).
-func NewTransmitter(binding string, cid [16]byte, sourceName string) (Transmitter, error) {
+//The source name has to be valid UTF-8 with at most 63 bytes.
+//The returned Transmitter must not be copied, therefore a pointer is returned.
+func NewTransmitter(binding string, cid [16]byte, sourceName string) (*Transmitter, error) {
+	if err := checkSourceName(sourceName); err != nil {
+		return nil, err
+	}
 	//create tranmsitter:
-	tx := Transmitter{
-		universes:    make(map[uint16]chan [512]byte),
-		master:       make(map[uint16]*DataPacket),
-		destinations: make(map[uint16][]net.UDPAddr),
-		multicast:    make(map[uint16]bool),
-		bind:         "",
-		cid:          cid,
-		sourceName:   sourceName,
+	tx := &Transmitter{
+		universes:     make(map[uint16]chan [512]byte),
+		stop:          make(map[uint16]chan struct{}),
+		done:          make(map[uint16]chan struct{}),
+		master:        make(map[uint16]*DataPacket),
+		destinations:  make(map[uint16][]net.UDPAddr),
+		multicast:     make(map[uint16]bool),
+		bind:          "",
+		cid:           cid,
+		sourceName:    sourceName,
+		sourceNames:   make(map[uint16]string),
+		frameInterval: time.Second / defaultFrameRate,
+		keepAlive:     keepAliveInterval,
+		syncAddress:   make(map[uint16]uint16),
+		forceSync:     make(map[uint16]bool),
+		syncSequence:  make(map[uint16]byte),
+		sequence:      make(map[uint16]byte),
+		multicastTTL:  defaultMulticastTTL,
+		loopback:      true,
 	}
 	//create a udp address for testing, if the given bind address is possible
 	addr, err := net.ResolveUDPAddr("udp", binding)
@@ -40,10 +92,10 @@ func NewTransmitter(binding string, cid [16]byte, sourceName string) (Transmitte
 		return tx, err
 	}
 	serv, err := net.ListenUDP("udp", addr)
-	serv.Close()
 	if err != nil {
 		return tx, err
 	}
+	serv.Close()
 	//if everything is ok, set the bind address string
 	tx.bind = binding
 	return tx, nil
@@ -51,63 +103,439 @@ func NewTransmitter(binding string, cid [16]byte, sourceName string) (Transmitte
 
 //Activate starts sending out DMX data on the given universe. It returns a channel that accepts
 //byte slices and transmittes them to the unicast or multicast destination.
-//If you want to deactivate the universe, simply close the channel.
+//Changed data is send out with the frame rate (see SetFrameRate) and if nothing changes, the last
+//data is send out as keep alive, see SetKeepAliveInterval.
+//If you want to deactivate the universe, simply close the channel or use Deactivate.
 func (t *Transmitter) Activate(universe uint16) (chan<- [512]byte, error) {
-	//check if the universe is already activated
-	if t.IsActivated(universe) {
-		return nil, fmt.Errorf("the given universe %v is already activated", universe)
-	}
-	//create udp socket
-	ServerAddr, err := net.ResolveUDPAddr("udp", t.bind)
-	if err != nil {
-		return nil, err
-	}
-	serv, err := net.ListenUDP("udp", ServerAddr)
+	serv, err := t.listen()
 	if err != nil {
 		return nil, err
 	}
 
 	ch := make(chan [512]byte)
-	t.universes[universe] = ch
+	stop := make(chan struct{})
+	done := make(chan struct{})
 	//init master packet
 	masterPacket := NewDataPacket()
 	masterPacket.SetCID(t.cid)
-	masterPacket.SetSourceName(t.sourceName)
 	masterPacket.SetUniverse(universe)
 	masterPacket.SetData(make([]byte, 512)) //set 0 data
+
+	t.mu.Lock()
+	//check if the universe is already activated
+	if _, ok := t.universes[universe]; ok {
+		t.mu.Unlock()
+		serv.Close()
+		return nil, fmt.Errorf("the given universe %v is already activated", universe)
+	}
+	masterPacket.SetSyncAddress(t.syncAddress[universe])
+	masterPacket.SetForceSync(t.forceSync[universe])
+	//continue the sequence of a previous activation, so that receivers that missed the stream
+	//terminated packet do not drop the new packets as out of order
+	masterPacket.SetSequence(t.sequence[universe])
+	masterPacket.SetSourceName(t.getSourceName(universe))
+	if err := masterPacket.Validate(); err != nil {
+		t.mu.Unlock()
+		serv.Close()
+		return nil, err
+	}
+	t.universes[universe] = ch
+	t.stop[universe] = stop
+	t.done[universe] = done
 	t.master[universe] = &masterPacket
+	if t.discoveryStop == nil {
+		t.discoveryStop = make(chan struct{})
+		go t.discover(t.discoveryStop)
+	}
+	t.mu.Unlock()
 
-	//make goroutine that sends out every second a "keep alive" packet
-	go func() {
-		for {
-			//if we have no master packet,break the loop
-			if _, ok := t.master[universe]; !ok {
-				break
-			}
-			t.sendOut(serv, universe)
-			time.Sleep(time.Second * 1)
-		}
-	}()
+	go t.transmit(serv, universe, ch, stop, done)
+
+	return ch, nil
+}
+
+//transmit is the goroutine for one universe. It sends out changed data with the frame rate and
+//a keep alive packet, if no data was send for a second.
+func (t *Transmitter) transmit(serv *net.UDPConn, universe uint16, ch <-chan [512]byte,
+	stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	var lastSend time.Time
+	pending := false //true, if there is changed data that was not send out yet
+	send := func() {
+		t.sendOut(serv, universe)
+		lastSend = time.Now()
+		pending = false
+	}
+	send() //send out the initial data immediately
 
-	go func() {
-		for i := range ch {
+	timer := time.NewTimer(t.getFrameInterval())
+	defer timer.Stop()
+Loop:
+	for {
+		select {
+		case i, ok := <-ch:
+			if !ok {
+				break Loop //the channel was closed, so we deactivate the universe
+			}
+			t.mu.Lock()
 			t.master[universe].SetData(i[:])
-			t.sendOut(serv, universe)
+			t.mu.Unlock()
+			//send immediately, if the last packet is long enough ago. Otherwise wait for the next frame
+			if time.Since(lastSend) >= t.getFrameInterval() {
+				send()
+			} else {
+				pending = true
+			}
+		case <-timer.C:
+			interval := t.getFrameInterval()
+			if elapsed := time.Since(lastSend); pending && elapsed < interval {
+				//data was send immediately in the meantime, so wait for the rest of its frame
+				timer.Reset(interval - elapsed)
+				continue
+			}
+			if pending || time.Since(lastSend) >= t.getKeepAliveInterval() {
+				send()
+			}
+			timer.Reset(interval)
+		case <-stop:
+			break Loop
 		}
-		//if the channel was closed we send a last packet with stream terminated bit set
-		t.master[universe].SetStreamTerminated(true)
+	}
+	//if the universe gets deactivated we send the last packets with stream terminated bit set
+	t.mu.Lock()
+	t.master[universe].SetStreamTerminated(true)
+	t.mu.Unlock()
+	for i := 0; i < terminatePackets; i++ {
 		t.sendOut(serv, universe)
-		//if the channel was closed, we deactivate the universe
-		delete(t.master, universe)
-		delete(t.universes, universe)
+	}
+	t.mu.Lock()
+	t.sequence[universe] = t.master[universe].Sequence()
+	delete(t.master, universe)
+	delete(t.universes, universe)
+	delete(t.stop, universe)
+	delete(t.done, universe)
+	//stop the universe discovery, if this was the last universe
+	if len(t.universes) == 0 && t.discoveryStop != nil {
+		close(t.discoveryStop)
+		t.discoveryStop = nil
+	}
+	t.mu.Unlock()
+	serv.Close()
+}
+
+//deactivated returns a channel that gets closed, when the universe is deactivated. The channel is
+//already closed, if the universe is not activated.
+func (t *Transmitter) deactivated(universe uint16) <-chan struct{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if done, ok := t.done[universe]; ok {
+		return done
+	}
+	done := make(chan struct{})
+	close(done)
+	return done
+}
+
+//discover sends out universe discovery packets for all activated universes every 10 seconds
+//until the stop channel is closed.
+func (t *Transmitter) discover(stop <-chan struct{}) {
+	serv, err := t.listen()
+	if err != nil {
+		return
+	}
+	defer serv.Close()
+
+	ticker := time.NewTicker(discoveryInterval)
+	defer ticker.Stop()
+	for {
+		for _, p := range buildDiscoveryPackets(t.cid, t.sourceName, t.GetActivated()) {
+			serv.WriteToUDP(p.Bytes(), generateMulticast(discoveryUniverse))
+		}
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+	}
+}
+
+//Deactivate stops sending out DMX data on the given universe. Three packets with the stream
+//terminated bit set are send out as required by E1.31, so that receivers know that this source has
+//stopped and can fail over to another source immediately.
+//Deactivate waits until the universe is stopped. Do not send any data to the channel of this universe
+//afterwards. Returns an error if the universe was not activated.
+func (t *Transmitter) Deactivate(universe uint16) error {
+	t.mu.Lock()
+	stop, ok := t.stop[universe]
+	done := t.done[universe]
+	if ok {
+		delete(t.stop, universe) //so that a second call can not close the channel again
+	}
+	t.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("the given universe %v is not activated", universe)
+	}
+	close(stop)
+	<-done
+	return nil
+}
+
+//Close deactivates all activated universes and waits until their stream terminated packets are send
+//out, see Deactivate. The socket for the sync packets is closed, too. The transmitter can still be
+//used afterwards.
+func (t *Transmitter) Close() error {
+	for _, universe := range t.GetActivated() {
+		t.Deactivate(universe) //fails only, if the universe was deactivated in the meantime
+	}
+	t.syncMu.Lock()
+	defer t.syncMu.Unlock()
+	if t.syncConn == nil {
+		return nil
+	}
+	err := t.syncConn.Close()
+	t.syncConn = nil
+	return err
+}
+
+//SetFrameRate sets the maximum number of packets per second that are send out on every universe,
+//if the data changes. Data that is given faster to the channel is not send out, only the latest data
+//is send with the next frame. The default frame rate is 44, some devices work better with eg 30 or 25.
+//Note that regardless of the frame rate the last data is send out as keep alive, see
+//SetKeepAliveInterval. Returns an error, if a frame is longer than the keep alive interval.
+func (t *Transmitter) SetFrameRate(fps int) error {
+	if fps <= 0 {
+		return fmt.Errorf("the frame rate has to be greater than 0, was %v", fps)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	interval := time.Second / time.Duration(fps)
+	if interval > t.keepAlive {
+		return fmt.Errorf("the frame interval %v is longer than the keep alive interval %v", interval, t.keepAlive)
+	}
+	t.frameInterval = interval
+	return nil
+}
+
+//SetKeepAliveInterval sets the interval in which the last data of a universe is send out again, if
+//the data has not changed. The default is one second, which is also the maximum of E1.31.
+//Returns an error, if the interval is longer than a second or shorter than a frame (see SetFrameRate).
+func (t *Transmitter) SetKeepAliveInterval(interval time.Duration) error {
+	if interval <= 0 || interval > keepAliveInterval {
+		return fmt.Errorf("the keep alive interval has to be in (0-%v], was %v", keepAliveInterval, interval)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if interval < t.frameInterval {
+		return fmt.Errorf("the keep alive interval %v is shorter than the frame interval %v", interval, t.frameInterval)
+	}
+	t.keepAlive = interval
+	return nil
+}
+
+func (t *Transmitter) getKeepAliveInterval() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.keepAlive
+}
+
+func (t *Transmitter) getFrameInterval() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.frameInterval
+}
+
+//SetMulticastTTL sets the TTL (hop limit) of multicast packets. The default is 1, so that the packets
+//do not leave the local network. If the multicast packets have to be routed to other networks, the TTL
+//has to be increased. The TTL is used for all universes that are activated afterwards, so call this
+//before Activate.
+func (t *Transmitter) SetMulticastTTL(ttl int) error {
+	if ttl < 0 || ttl > 255 {
+		return fmt.Errorf("the TTL has to be in [0-255], was %v", ttl)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.multicastTTL = ttl
+	return nil
+}
+
+//listen creates a new udp socket on the bind address with the multicast settings of the transmitter
+func (t *Transmitter) listen() (*net.UDPConn, error) {
+	ServerAddr, err := net.ResolveUDPAddr("udp", t.bind)
+	if err != nil {
+		return nil, err
+	}
+	serv, err := net.ListenUDP("udp", ServerAddr)
+	if err != nil {
+		return nil, err
+	}
+	t.mu.Lock()
+	ttl := t.multicastTTL
+	loopback := t.loopback
+	ifi := t.outputIfi
+	t.mu.Unlock()
+	conn := ipv4.NewPacketConn(serv)
+	if ifi != nil {
+		if err := conn.SetMulticastInterface(ifi); err != nil {
+			serv.Close()
+			return nil, err
+		}
+	}
+	if err := conn.SetMulticastTTL(ttl); err != nil {
+		serv.Close()
+		return nil, err
+	}
+	if err := conn.SetMulticastLoopback(loopback); err != nil {
 		serv.Close()
-	}()
+		return nil, err
+	}
+	return serv, nil
+}
 
-	return ch, nil
+//SetMulticastLoopback sets wether multicast packets are also delivered to receivers on this host.
+//The default is true. Disable it, if a receiver on the same host should not receive the packets
+//of this transmitter. Like SetMulticastTTL, this is used for all universes that are activated afterwards.
+func (t *Transmitter) SetMulticastLoopback(loopback bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.loopback = loopback
+}
+
+//SetSourceName sets the source name that is used for the universe instead of the global source name
+//of the transmitter. This can be used, if one transmitter emulates multiple devices. Use an empty name
+//to use the global source name again. Universe discovery packets always use the global source name.
+//Returns an error, if the name is not valid UTF-8 or longer than 63 bytes.
+func (t *Transmitter) SetSourceName(universe uint16, name string) error {
+	if err := checkSourceName(name); err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if name == "" {
+		delete(t.sourceNames, universe)
+	} else {
+		t.sourceNames[universe] = name
+	}
+	if packet, ok := t.master[universe]; ok {
+		packet.SetSourceName(t.getSourceName(universe))
+	}
+	return nil
+}
+
+//getSourceName returns the source name that is used for the universe. The caller has to hold the mu.
+func (t *Transmitter) getSourceName(universe uint16) string {
+	if name, ok := t.sourceNames[universe]; ok {
+		return name
+	}
+	return t.sourceName
+}
+
+//SetOutputInterface sets the network interface that is used for sending multicast packets. On hosts
+//with multiple interfaces this ensures that sACN is send out on the lighting network, instead of the
+//interface that the operating system chooses. Use nil to let the operating system choose again.
+//Returns an error, if the interface has no IPv4 address. The setting is applied to universes that
+//are activated afterwards.
+func (t *Transmitter) SetOutputInterface(ifi *net.Interface) error {
+	if ifi != nil {
+		addrs, err := ifi.Addrs()
+		if err != nil {
+			return err
+		}
+		usable := false
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.To4() != nil {
+				usable = true
+			}
+		}
+		if !usable {
+			return fmt.Errorf("the interface %v has no IPv4 address", ifi.Name)
+		}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.outputIfi = ifi
+	return nil
+}
+
+//SetSyncUniverse sets the universe that is used for synchronizing the data of the given universe.
+//Receivers hold back the data of the universe, until a sync packet is send on the sync universe
+//via SendSync. Use 0 to disable synchronization for the universe, which is the default.
+func (t *Transmitter) SetSyncUniverse(universe, syncUniverse uint16) error {
+	if syncUniverse != 0 {
+		if err := checkUniverse(syncUniverse); err != nil {
+			return err
+		}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.syncAddress[universe] = syncUniverse
+	if packet, ok := t.master[universe]; ok {
+		packet.SetSyncAddress(syncUniverse)
+	}
+	return nil
+}
+
+//SetForceSync sets the force_synchronization flag in the packets of the universe. With the flag set,
+//receivers that have lost the synchronization, eg because they missed the sync packets, process the
+//data of the universe without waiting for a sync packet. Without the flag, they hold the data until
+//the synchronization is back. The flag has only an effect, if a sync universe is set.
+func (t *Transmitter) SetForceSync(universe uint16, forceSync bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.forceSync[universe] = forceSync
+	if packet, ok := t.master[universe]; ok {
+		packet.SetForceSync(forceSync)
+	}
+}
+
+//SendSync sends out a sync packet on the given sync universe. All receivers then process the data of
+//universes that use this sync universe at once. The packet is send via multicast, if multicast is
+//turned on for the sync universe, and to all destinations of the sync universe.
+//See SetMulticast and SetDestinations.
+//All sync packets are send with one socket, that is opened by the first call. Like the sockets of the
+//universes it keeps the multicast options that were set before, and it is closed by Close.
+func (t *Transmitter) SendSync(syncUniverse uint16) error {
+	if err := checkUniverse(syncUniverse); err != nil {
+		return err
+	}
+	t.syncMu.Lock()
+	defer t.syncMu.Unlock()
+	if t.syncConn == nil {
+		serv, err := t.listen()
+		if err != nil {
+			return err
+		}
+		t.syncConn = serv
+	}
+	serv := t.syncConn
+
+	t.mu.Lock()
+	t.syncSequence[syncUniverse]++
+	packet := NewSyncPacket()
+	packet.SetCID(t.cid)
+	packet.SetSequence(t.syncSequence[syncUniverse])
+	packet.SetSyncAddress(syncUniverse)
+	multicast := t.multicast[syncUniverse]
+	destinations := append([]net.UDPAddr(nil), t.destinations[syncUniverse]...)
+	t.mu.Unlock()
+
+	if multicast {
+		if _, err := serv.WriteToUDP(packet.Bytes(), generateMulticast(syncUniverse)); err != nil {
+			return err
+		}
+	}
+	for _, dest := range destinations {
+		if _, err := serv.WriteToUDP(packet.Bytes(), &dest); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 //IsActivated checks if the given universe was activated and returns true if this is the case
 func (t *Transmitter) IsActivated(universe uint16) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	if _, ok := t.universes[universe]; ok {
 		return true
 	}
@@ -116,6 +544,8 @@ func (t *Transmitter) IsActivated(universe uint16) bool {
 
 //GetActivated returns a slice with all activated universes
 func (t *Transmitter) GetActivated() (list []uint16) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	list = make([]uint16, 0)
 	for univ := range t.universes {
 		list = append(list, univ)
@@ -126,11 +556,15 @@ func (t *Transmitter) GetActivated() (list []uint16) {
 //SetMulticast is for setting wether or not a universe should be send out via multicast.
 //Keep in mind, that on some operating systems you have to provide a bind address.
 func (t *Transmitter) SetMulticast(universe uint16, multicast bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	t.multicast[universe] = multicast
 }
 
 //IsMulticast returns wether or not multicast is turned on for the given universe. true: on
 func (t *Transmitter) IsMulticast(universe uint16) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	return t.multicast[universe]
 }
 
@@ -147,14 +581,16 @@ func (t *Transmitter) SetDestinations(universe uint16, destinations []string) []
 		if dest == "" {
 			continue // continue if the string is empty
 		}
-		addr, err := net.ResolveUDPAddr("udp", dest+":5568")
+		addr, err := net.ResolveUDPAddr("udp", net.JoinHostPort(dest, "5568"))
 		if err != nil {
 			errs = append(errs, err)
 			continue
 		}
 		newDest = append(newDest, *addr)
 	}
+	t.mu.Lock()
 	t.destinations[universe] = newDest
+	t.mu.Unlock()
 
 	if len(errs) == 0 {
 		return nil
@@ -162,9 +598,29 @@ func (t *Transmitter) SetDestinations(universe uint16, destinations []string) []
 	return errs
 }
 
-//Destinations returns all destinations that have been set via SetDestinations. Note: the returned
-//slice contains deep copys and no change will affect the internal slice.
+//AddDestination adds the ip-address as unicast destination for the universe. Unicast and multicast
+//can be used at the same time, see SetMulticast. Returns an error if the address is not a valid unicast
+//address, or if it is already a destination of the universe.
+func (t *Transmitter) AddDestination(universe uint16, dst net.IP) error {
+	if dst == nil || dst.IsUnspecified() || dst.IsMulticast() {
+		return fmt.Errorf("%v is not a valid unicast destination", dst)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, dest := range t.destinations[universe] {
+		if dest.IP.Equal(dst) {
+			return fmt.Errorf("%v is already a destination of universe %v", dst, universe)
+		}
+	}
+	t.destinations[universe] = append(t.destinations[universe], net.UDPAddr{IP: dst, Port: 5568})
+	return nil
+}
+
+//Destinations returns all destinations that have been set via SetDestinations and AddDestination.
+//Note: the returned slice contains deep copys and no change will affect the internal slice.
 func (t *Transmitter) Destinations(universe uint16) []net.UDPAddr {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	new := make([]net.UDPAddr, len(t.destinations[universe]))
 	copy(new, t.destinations[universe])
 	return new
@@ -172,20 +628,28 @@ func (t *Transmitter) Destinations(universe uint16) []net.UDPAddr {
 
 //handles sending and sequence numbering
 func (t *Transmitter) sendOut(server *net.UDPConn, universe uint16) {
+	t.mu.Lock()
 	//only send if the universe was activated
-	if _, ok := t.master[universe]; !ok {
+	packet, ok := t.master[universe]
+	if !ok {
+		t.mu.Unlock()
 		return
 	}
 	//increase seqeunce number
-	packet := t.master[universe]
 	packet.SequenceIncr()
+	//copy everything we need, so that we do not hold the lock while sending
+	bytes := packet.Bytes()
+	multicast := t.multicast[universe]
+	destinations := append([]net.UDPAddr(nil), t.destinations[universe]...)
+	t.mu.Unlock()
+
 	//check if we have to transmitt via multicast
-	if t.multicast[universe] {
-		server.WriteToUDP(packet.getBytes(), generateMulticast(universe))
+	if multicast {
+		server.WriteToUDP(bytes, generateMulticast(universe))
 	}
 	//for every destination, send out
-	for _, dest := range t.destinations[universe] {
-		server.WriteToUDP(packet.getBytes(), &dest)
+	for _, dest := range destinations {
+		server.WriteToUDP(bytes, &dest)
 	}
 }
 
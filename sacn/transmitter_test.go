@@ -0,0 +1,565 @@
+package sacn
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"golang.org/x/net/ipv4"
+)
+
+//listenTestPackets listens on the sACN port of localhost and returns all received data packets
+func listenTestPackets(t *testing.T) (<-chan DataPacket, func()) {
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:5568")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch := make(chan DataPacket, 100)
+	go func() {
+		buf := make([]byte, 638)
+		for {
+			n, _, err := conn.ReadFrom(buf)
+			if err != nil {
+				close(ch)
+				return
+			}
+			if p, err := NewDataPacketRaw(buf[:n]); err == nil {
+				ch <- p
+			}
+		}
+	}()
+	return ch, func() { conn.Close() }
+}
+
+func TestTransmitterActivateDeactivate(t *testing.T) {
+	packets, stop := listenTestPackets(t)
+	defer stop()
+
+	trans, err := NewTransmitter("127.0.0.1:0", [16]byte{1, 2, 3}, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	trans.SetDestinations(1, []string{"127.0.0.1"})
+	ch, err := trans.Activate(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := trans.Activate(1); err == nil {
+		t.Error("Activating an already activated universe should fail!")
+	}
+	if _, err := trans.Activate(0); err == nil || trans.IsActivated(0) {
+		t.Error("Activating an invalid universe should fail!")
+	}
+	ch <- [512]byte{1, 2, 3}
+
+	//the first packet is the initial zero data, the second one our data
+	first := <-packets
+	second := <-packets
+	if first.Universe() != 1 || second.Data()[0] != 1 {
+		t.Errorf("Wrong packets received! Was: %v, %v", first.Data()[:3], second.Data()[:3])
+	}
+	if second.Sequence() != first.Sequence()+1 {
+		t.Errorf("Sequence was not incremented! Was: %v, %v", first.Sequence(), second.Sequence())
+	}
+
+	if err := trans.Deactivate(1); err != nil {
+		t.Fatal(err)
+	}
+	if trans.IsActivated(1) {
+		t.Error("Universe should not be active after Deactivate!")
+	}
+	if err := trans.Deactivate(1); err == nil {
+		t.Error("Deactivating a not activated universe should fail!")
+	}
+	//three stream terminated packets with incremented sequence numbers
+	sequence := second.Sequence()
+	for i := 0; i < 3; i++ {
+		select {
+		case p := <-packets:
+			if !p.StreamTerminated() {
+				t.Error("Last packets should have the stream terminated bit set!")
+			}
+			if p.Sequence() != sequence+1 {
+				t.Errorf("Sequence was not incremented! Was: %v after %v", p.Sequence(), sequence)
+			}
+			sequence = p.Sequence()
+		case <-time.After(time.Second):
+			t.Fatalf("Only %v stream terminated packets were received!", i)
+		}
+	}
+}
+
+func TestTransmitterClose(t *testing.T) {
+	packets, stop := listenTestPackets(t)
+	defer stop()
+
+	trans, err := NewTransmitter("127.0.0.1:0", [16]byte{1, 2, 3}, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, universe := range []uint16{1, 2} {
+		trans.SetDestinations(universe, []string{"127.0.0.1"})
+		if _, err := trans.Activate(universe); err != nil {
+			t.Fatal(err)
+		}
+		<-packets //initial packet
+	}
+	if err := trans.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if activated := trans.GetActivated(); len(activated) != 0 {
+		t.Errorf("All universes should have been deactivated! Was: %v", activated)
+	}
+	terminated := make(map[uint16]int)
+	for i := 0; i < 6; i++ {
+		select {
+		case p := <-packets:
+			if p.StreamTerminated() {
+				terminated[p.Universe()]++
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Only %v stream terminated packets were received!", i)
+		}
+	}
+	if terminated[1] != 3 || terminated[2] != 3 {
+		t.Errorf("Every universe should send 3 stream terminated packets! Was: %v", terminated)
+	}
+}
+
+func TestTransmitterKeepAlive(t *testing.T) {
+	packets, stop := listenTestPackets(t)
+	defer stop()
+
+	trans, err := NewTransmitter("127.0.0.1:0", [16]byte{1, 2, 3}, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	trans.SetDestinations(2, []string{"127.0.0.1"})
+	if _, err := trans.Activate(2); err != nil {
+		t.Fatal(err)
+	}
+	defer trans.Deactivate(2)
+	<-packets //initial packet
+	select {
+	case <-packets:
+	case <-time.After(keepAliveInterval + 200*time.Millisecond):
+		t.Error("No keep alive packet was send!")
+	}
+}
+
+func TestSetFrameRate(t *testing.T) {
+	trans, err := NewTransmitter("", [16]byte{}, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := trans.SetFrameRate(0); err == nil {
+		t.Error("A frame rate of 0 should be rejected!")
+	}
+	if err := trans.SetFrameRate(25); err != nil {
+		t.Error(err)
+	}
+	if trans.getFrameInterval() != 40*time.Millisecond {
+		t.Errorf("Wrong frame interval! Was: %v", trans.getFrameInterval())
+	}
+}
+
+func TestSetKeepAliveInterval(t *testing.T) {
+	trans, err := NewTransmitter("", [16]byte{}, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, interval := range []time.Duration{0, 2 * time.Second, 10 * time.Millisecond} {
+		if err := trans.SetKeepAliveInterval(interval); err == nil {
+			t.Errorf("A keep alive interval of %v should be rejected!", interval)
+		}
+	}
+	if err := trans.SetKeepAliveInterval(100 * time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := trans.SetFrameRate(5); err == nil {
+		t.Error("A frame that is longer than the keep alive interval should be rejected!")
+	}
+	if err := trans.SetFrameRate(10); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestKeepAliveCadence(t *testing.T) {
+	packets, stop := listenTestPackets(t)
+	defer stop()
+
+	trans, err := NewTransmitter("127.0.0.1:0", [16]byte{1, 2, 3}, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	trans.SetFrameRate(25) //one packet every 40ms
+	trans.SetKeepAliveInterval(200 * time.Millisecond)
+	trans.SetDestinations(5, []string{"127.0.0.1"})
+	ch, err := trans.Activate(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer trans.Deactivate(5)
+	<-packets //initial packet
+
+	//without changes, the data is send with the keep alive interval
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		<-packets
+	}
+	if elapsed := time.Since(start); elapsed < 550*time.Millisecond || elapsed > 900*time.Millisecond {
+		t.Errorf("3 keep alive packets should take about 600ms, took %v", elapsed)
+	}
+	//changes are send with the frame rate
+	start = time.Now()
+	for i := 0; i < 5; i++ {
+		ch <- [512]byte{byte(i + 1)}
+		<-packets
+	}
+	if elapsed := time.Since(start); elapsed > 400*time.Millisecond {
+		t.Errorf("5 changes should be send within about 200ms, took %v", elapsed)
+	}
+}
+
+func TestFrameRateCadence(t *testing.T) {
+	packets, stop := listenTestPackets(t)
+	defer stop()
+
+	trans, err := NewTransmitter("127.0.0.1:0", [16]byte{1, 2, 3}, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	trans.SetFrameRate(20) //one packet every 50ms
+	trans.SetDestinations(3, []string{"127.0.0.1"})
+	ch, err := trans.Activate(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer trans.Deactivate(3)
+	<-packets //initial packet
+
+	//flood the transmitter with data for 500ms
+	sent := 0
+	for start := time.Now(); time.Since(start) < 500*time.Millisecond; sent++ {
+		ch <- [512]byte{byte(sent%255 + 1)}
+	}
+	last := byte((sent-1)%255 + 1)
+	received := 0
+	for {
+		select {
+		case p := <-packets:
+			received++
+			if p.Data()[0] != last {
+				continue
+			}
+		case <-time.After(time.Second):
+			t.Fatal("The latest data was not send out!")
+		}
+		break
+	}
+	//500ms with 20fps are 10 packets, allow some tolerance for the timer
+	if received > 13 || received >= sent {
+		t.Errorf("Too many packets were send! Send %v packets for %v frames", received, sent)
+	}
+}
+
+func BenchmarkTransmitterFlood(b *testing.B) {
+	trans, err := NewTransmitter("127.0.0.1:0", [16]byte{1, 2, 3}, "test")
+	if err != nil {
+		b.Fatal(err)
+	}
+	trans.SetDestinations(4, []string{"127.0.0.1"})
+	ch, err := trans.Activate(4)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer trans.Deactivate(4)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ch <- [512]byte{byte(i)}
+	}
+}
+
+func TestTransmitterSync(t *testing.T) {
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:5568")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	trans, err := NewTransmitter("127.0.0.1:0", [16]byte{1, 2, 3}, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := trans.SetSyncUniverse(1, 64000); err == nil {
+		t.Error("Invalid sync universe should be rejected!")
+	}
+	trans.SetDestinations(7, []string{"127.0.0.1"})
+	senders := make(map[string]bool) //the source addresses of the sync packets
+	for i := 1; i <= 3; i++ {
+		if i == 3 {
+			//the socket is opened again after Close
+			if err := trans.Close(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := trans.SendSync(7); err != nil {
+			t.Fatal(err)
+		}
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		buf := make([]byte, 638)
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i <= 2 {
+			senders[addr.String()] = true
+		}
+		p, err := NewSyncPacketRaw(buf[:n])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.SyncAddress() != 7 || p.Sequence() != byte(i) || p.CID() != [16]byte{1, 2, 3} {
+			t.Errorf("Wrong sync packet! Address: %v, Sequence: %v", p.SyncAddress(), p.Sequence())
+		}
+	}
+	if len(senders) != 1 {
+		t.Errorf("The sync packets should be send with one socket, were send from %v", senders)
+	}
+	trans.Close()
+}
+
+func TestTransmitterForceSync(t *testing.T) {
+	packets, stop := listenTestPackets(t)
+	defer stop()
+
+	trans, err := NewTransmitter("127.0.0.1:0", [16]byte{1, 2, 3}, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	trans.SetDestinations(1, []string{"127.0.0.1"})
+	trans.SetSyncUniverse(1, 7)
+	trans.SetForceSync(1, true)
+	ch, err := trans.Activate(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer trans.Deactivate(1)
+	p := <-packets
+	if p.Options()&OptionForceSync == 0 || !p.ForceSync() || p.SyncAddress() != 7 {
+		t.Errorf("The force_synchronization flag was not set! Options: %08b", p.Options())
+	}
+	trans.SetForceSync(1, false)
+	ch <- [512]byte{1}
+	p = <-packets
+	if p.ForceSync() {
+		t.Errorf("The force_synchronization flag should have been cleared! Options: %08b", p.Options())
+	}
+}
+
+func TestSetMulticastTTL(t *testing.T) {
+	tx, err := NewTransmitter("", [16]byte{1}, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.SetMulticastTTL(256); err == nil {
+		t.Error("A TTL of 256 should not be allowed!")
+	}
+	for _, ttl := range []int{defaultMulticastTTL, 32} {
+		if ttl != defaultMulticastTTL {
+			if err := tx.SetMulticastTTL(ttl); err != nil {
+				t.Fatal(err)
+			}
+		}
+		conn, err := tx.listen()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ipv4.NewPacketConn(conn).MulticastTTL()
+		conn.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != ttl {
+			t.Errorf("Wrong TTL! Was: %v; Should've been: %v", got, ttl)
+		}
+	}
+}
+
+func TestSetMulticastLoopback(t *testing.T) {
+	tx, err := NewTransmitter("", [16]byte{1}, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, loopback := range []bool{true, false} {
+		tx.SetMulticastLoopback(loopback)
+		conn, err := tx.listen()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ipv4.NewPacketConn(conn).MulticastLoopback()
+		conn.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != loopback {
+			t.Errorf("Wrong loopback setting! Was: %v; Should've been: %v", got, loopback)
+		}
+	}
+}
+
+func TestAddDestination(t *testing.T) {
+	packets, stop := listenTestPackets(t)
+	defer stop()
+
+	trans, err := NewTransmitter("127.0.0.1:0", [16]byte{1, 2, 3}, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dst := range []net.IP{nil, net.IPv4zero, net.IPv4(239, 255, 0, 1)} {
+		if err := trans.AddDestination(1, dst); err == nil {
+			t.Errorf("%v should not be a valid destination!", dst)
+		}
+	}
+	if err := trans.AddDestination(1, net.IPv4(127, 0, 0, 1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := trans.AddDestination(1, net.IPv4(127, 0, 0, 1)); err == nil {
+		t.Error("Adding a destination twice should fail!")
+	}
+	if dests := trans.Destinations(1); len(dests) != 1 || dests[0].Port != 5568 {
+		t.Errorf("Wrong destinations! Was: %v", dests)
+	}
+	ch, err := trans.Activate(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer trans.Deactivate(1)
+	ch <- [512]byte{1}
+	select {
+	case p := <-packets:
+		if p.Universe() != 1 {
+			t.Errorf("Wrong universe! Was: %v", p.Universe())
+		}
+	case <-time.After(time.Second):
+		t.Error("No packet was received on the unicast destination!")
+	}
+}
+
+func TestTransmitterSetSourceName(t *testing.T) {
+	trans, err := NewTransmitter("127.0.0.1:0", [16]byte{1, 2, 3}, "global")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := trans.SetSourceName(1, "\xff\xfe"); err == nil {
+		t.Error("Invalid UTF-8 should not be accepted!")
+	}
+	if err := trans.SetSourceName(1, string(make([]byte, 64))); err == nil {
+		t.Error("A source name with 64 bytes should not be accepted!")
+	}
+	if err := trans.SetSourceName(1, "device 1"); err != nil {
+		t.Fatal(err)
+	}
+	for _, univ := range []uint16{1, 2} {
+		if _, err := trans.Activate(univ); err != nil {
+			t.Fatal(err)
+		}
+		defer trans.Deactivate(univ)
+	}
+	name := func(universe uint16) string {
+		trans.mu.Lock()
+		defer trans.mu.Unlock()
+		return trans.master[universe].SourceName()
+	}
+	if name(1) != "device 1" || name(2) != "global" {
+		t.Errorf("Wrong source names! Was: %q, %q", name(1), name(2))
+	}
+	//the name of an active universe can be changed and reset
+	trans.SetSourceName(2, "device 2")
+	trans.SetSourceName(1, "")
+	if name(1) != "global" || name(2) != "device 2" {
+		t.Errorf("Wrong source names after change! Was: %q, %q", name(1), name(2))
+	}
+}
+
+func TestSetOutputInterface(t *testing.T) {
+	tx, err := NewTransmitter("", [16]byte{1}, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.SetOutputInterface(&net.Interface{Index: 9999, Name: "none"}); err == nil {
+		t.Error("An interface without address should not be accepted!")
+	}
+	var lo *net.Interface
+	ifis, _ := net.Interfaces()
+	for i := range ifis {
+		if ifis[i].Flags&net.FlagLoopback != 0 {
+			lo = &ifis[i]
+		}
+	}
+	if lo == nil {
+		t.Skip("no loopback interface")
+	}
+	if err := tx.SetOutputInterface(lo); err != nil {
+		t.Fatal(err)
+	}
+	//the interface can not be read back on every system (Linux only returns the address, if the
+	//interface was set via its address), so check that it is stored and the socket accepts it
+	tx.mu.Lock()
+	ifi := tx.outputIfi
+	tx.mu.Unlock()
+	if ifi != lo {
+		t.Errorf("Wrong output interface! Was: %v", ifi)
+	}
+	conn, err := tx.listen()
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+	if err := tx.SetOutputInterface(nil); err != nil || tx.outputIfi != nil {
+		t.Errorf("The output interface was not reset! Was: %v, %v", tx.outputIfi, err)
+	}
+}
+
+func TestTransmitterReactivate(t *testing.T) {
+	packets, stop := listenTestPackets(t)
+	defer stop()
+
+	trans, err := NewTransmitter("127.0.0.1:0", [16]byte{1, 2, 3}, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	trans.SetDestinations(3, []string{"127.0.0.1"})
+	ch, err := trans.Activate(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-packets //initial packet
+	ch <- [512]byte{1}
+	<-packets
+	trans.Deactivate(3)
+	var terminated DataPacket
+	for i := 0; i < 3; i++ {
+		terminated = <-packets
+		if !terminated.StreamTerminated() {
+			t.Fatal("Last packets should have the stream terminated bit set!")
+		}
+	}
+
+	//receivers that missed the terminated packet must not drop the new stream as out of order
+	if _, err := trans.Activate(3); err != nil {
+		t.Fatal(err)
+	}
+	defer trans.Deactivate(3)
+	select {
+	case p := <-packets:
+		if !checkSequ(terminated.Sequence(), p.Sequence()) {
+			t.Errorf("The sequence was not continued! Was: %v after %v", p.Sequence(), terminated.Sequence())
+		}
+		if p.StreamTerminated() || p.Data()[0] != 0 {
+			t.Errorf("The reactivated universe did not start fresh! Data: %v", p.Data()[:3])
+		}
+	case <-time.After(time.Second):
+		t.Error("No packet was received after the reactivation!")
+	}
+}
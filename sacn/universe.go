@@ -0,0 +1,71 @@
+package sacn
+
+import "sync"
+
+//Universe holds the current DMX data of one universe of a receiver, so that channels can be read at
+//any time without handling packets. It is updated with every frame of the source that is used for
+//the universe. After a timeout or a terminated stream, the last data is kept.
+//A Universe is safe for concurrent use.
+type Universe struct {
+	receiver *ReceiverSocket
+	universe uint16
+	mu       sync.RWMutex
+	data     [512]byte
+}
+
+//NewUniverse creates a Universe that is updated by the receiver with the data of the given universe.
+//It starts with the last data of the universe or with all channels at 0. This does not use the
+//handler of OnData, so both can be used at the same time. Call Close, if the Universe is not needed
+//anymore.
+func NewUniverse(r *ReceiverSocket, universe uint16) *Universe {
+	u := &Universe{receiver: r, universe: universe}
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+	if last, ok := r.lastDatas[universe]; ok {
+		copy(u.data[:], last.lastPacket.Data())
+	}
+	r.views[universe] = append(r.views[universe], u)
+	return u
+}
+
+//update sets the data to the data of the packet. Channels that are not contained in the packet are 0.
+func (u *Universe) update(p DataPacket) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.data = [512]byte{}
+	copy(u.data[:], p.Data())
+}
+
+//Get returns the value of the DMX channel with the address in [1-512]. Other addresses return 0.
+func (u *Universe) Get(addr int) byte {
+	if addr < 1 || addr > 512 {
+		return 0
+	}
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	return u.data[addr-1]
+}
+
+//Snapshot returns the values of all DMX channels
+func (u *Universe) Snapshot() [512]byte {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	return u.data
+}
+
+//Close stops updating the Universe. The last data can still be read.
+func (u *Universe) Close() {
+	r := u.receiver
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+	views := r.views[u.universe]
+	for i, view := range views {
+		if view == u {
+			r.views[u.universe] = append(views[:i:i], views[i+1:]...)
+			break
+		}
+	}
+	if len(r.views[u.universe]) == 0 {
+		delete(r.views, u.universe)
+	}
+}
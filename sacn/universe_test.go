@@ -0,0 +1,43 @@
+package sacn
+
+import "testing"
+
+func TestUniverse(t *testing.T) {
+	r := newTestReceiver()
+	p := NewDataPacket()
+	p.SetUniverse(1)
+	p.SetData([]byte{1, 2, 3})
+	r.handle(p)
+
+	u := NewUniverse(r, 1)
+	if u.Get(1) != 1 || u.Get(3) != 3 || u.Get(512) != 0 {
+		t.Errorf("The last data was not used! Was: %v, %v, %v", u.Get(1), u.Get(3), u.Get(512))
+	}
+	if u.Get(0) != 0 || u.Get(513) != 0 {
+		t.Error("Invalid addresses should return 0!")
+	}
+	other := NewUniverse(r, 2)
+
+	//the data is updated synchronously with every frame
+	p.SequenceIncr()
+	p.SetData([]byte{10})
+	r.handle(p)
+	if snapshot := u.Snapshot(); snapshot[0] != 10 || snapshot[2] != 0 {
+		t.Errorf("Wrong data after an update! Was: %v", snapshot[:4])
+	}
+	if other.Snapshot() != [512]byte{} {
+		t.Error("The data of another universe was used!")
+	}
+
+	u.Close()
+	other.Close()
+	if len(r.views) != 0 {
+		t.Errorf("The universes were not removed from the receiver! Was: %v", r.views)
+	}
+	p.SequenceIncr()
+	p.SetData([]byte{20})
+	r.handle(p)
+	if u.Get(1) != 10 {
+		t.Errorf("A closed universe should not be updated! Was: %v", u.Get(1))
+	}
+}